@@ -0,0 +1,135 @@
+package _cache
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/cache/mocks"
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchCache(t *testing.T) {
+	tests := []struct {
+		name string
+		// newCache 返回缓存以及让时间前进的函数
+		newCache func(t *testing.T) (BatchCache, func(d time.Duration))
+	}{
+		{
+			name: "local cache",
+			newCache: func(t *testing.T) (BatchCache, func(d time.Duration)) {
+				fake := clock.NewFake(time.Now())
+				c := NewLocalCache(time.Hour, WithClock(fake))
+				t.Cleanup(func() { _ = c.Close() })
+				return c, fake.Advance
+			},
+		},
+		{
+			name: "max cnt cache",
+			newCache: func(t *testing.T) (BatchCache, func(d time.Duration)) {
+				fake := clock.NewFake(time.Now())
+				local := NewLocalCache(time.Hour, WithClock(fake))
+				t.Cleanup(func() { _ = local.Close() })
+				return NewMaxCntCache(10, local), fake.Advance
+			},
+		},
+		{
+			name: "redis cache",
+			newCache: func(t *testing.T) (BatchCache, func(d time.Duration)) {
+				mr := miniredis.RunT(t)
+				client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+				t.Cleanup(func() { _ = client.Close() })
+				return NewRedisCache(client), mr.FastForward
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, advance := tt.newCache(t)
+			var mu sync.Mutex
+			var evicted []string
+			c.OnEvicted(func(key string, value []byte) {
+				mu.Lock()
+				evicted = append(evicted, key)
+				mu.Unlock()
+			})
+			ctx := context.Background()
+
+			require.NoError(t, c.MSet(ctx, map[string][]byte{
+				"key1": []byte("value1"),
+				"key2": []byte("value2"),
+			}, 0))
+			require.NoError(t, c.MSet(ctx, map[string][]byte{"key3": []byte("value3")}, time.Second))
+			values, err := c.MGet(ctx, []string{"key1", "key2", "key3", "not-exist"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string][]byte{
+				"key1": []byte("value1"),
+				"key2": []byte("value2"),
+				"key3": []byte("value3"),
+			}, values)
+
+			// 过期的 key 不返回
+			advance(2 * time.Second)
+			values, err = c.MGet(ctx, []string{"key1", "key3"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string][]byte{"key1": []byte("value1")}, values)
+
+			mu.Lock()
+			evicted = nil
+			mu.Unlock()
+			require.NoError(t, c.MDelete(ctx, []string{"key1", "key2", "not-exist"}))
+			values, err = c.MGet(ctx, []string{"key1", "key2"})
+			require.NoError(t, err)
+			assert.Empty(t, values)
+			mu.Lock()
+			sort.Strings(evicted)
+			assert.Equal(t, []string{"key1", "key2"}, evicted)
+			mu.Unlock()
+		})
+	}
+}
+
+func TestLocalCache_BatchClosed(t *testing.T) {
+	c := NewLocalCache(time.Minute)
+	require.NoError(t, c.Close())
+	ctx := context.Background()
+	_, err := c.MGet(ctx, []string{"key"})
+	assert.ErrorIs(t, err, ErrCacheClosed)
+	assert.ErrorIs(t, c.MSet(ctx, map[string][]byte{"key": nil}, 0), ErrCacheClosed)
+	assert.ErrorIs(t, c.MDelete(ctx, []string{"key"}), ErrCacheClosed)
+}
+
+func TestMaxCntCache_MSet(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := NewMaxCntCache(2, local)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+	// MGet 记录访问，key1 变为最近使用
+	_, err := c.MGet(ctx, []string{"key1"})
+	require.NoError(t, err)
+	require.NoError(t, c.MSet(ctx, map[string][]byte{"key3": []byte("value3")}, 0))
+	assert.True(t, c.Exists(ctx, "key1"))
+	assert.False(t, c.Exists(ctx, "key2"))
+	assert.Equal(t, int32(2), c.cnt)
+}
+
+func TestRedisCache_MGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cmd := mocks.NewMockCmdable(ctrl)
+	res := redis.NewSliceCmd(context.Background())
+	res.SetVal([]any{"value1", nil})
+	cmd.EXPECT().MGet(gomock.Any(), "key1", "key2").Return(res)
+
+	values, err := NewRedisCache(cmd).MGet(context.Background(), []string{"key1", "key2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"key1": []byte("value1")}, values)
+}
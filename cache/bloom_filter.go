@@ -0,0 +1,154 @@
+package _cache
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	_ "embed"
+)
+
+var (
+	//go:embed lua/bloom_add.lua
+	luaBloomAdd string
+
+	//go:embed lua/bloom_exists.lua
+	luaBloomExists string
+)
+
+var (
+	_ BloomFilter = &MemoryBloomFilter{}
+	_ BloomFilter = &RedisBloomFilter{}
+)
+
+// bloomSize 根据预期元素数量 n 与误判率 p 计算位数组大小 m 与哈希函数个数 k
+// m = -n*ln(p)/(ln2)^2, k = m/n*ln2
+func bloomSize(n uint64, p float64) (m uint64, k uint64) {
+	if n == 0 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m = uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k = uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	return max(m, 1), max(k, 1)
+}
+
+// bloomLocations 使用双重哈希 h1+i*h2 生成 k 个位置
+// 只依赖 key 本身，保证不同实例对同一个 key 计算出相同的位置
+func bloomLocations(key string, m, k uint64) []uint64 {
+	h := fnv.New128a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum(nil)
+	h1 := binary.BigEndian.Uint64(sum[:8])
+	h2 := binary.BigEndian.Uint64(sum[8:]) | 1 // 避免 h2 为 0 时所有位置相同
+	locations := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		locations[i] = (h1 + i*h2) % m
+	}
+	return locations
+}
+
+// MemoryBloomFilter 基于内存位数组的布隆过滤器，只在单个实例内有效
+type MemoryBloomFilter struct {
+	mu   sync.RWMutex
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// NewMemoryBloomFilter expectedItems 为预期元素数量，falsePositiveRate 为期望的误判率
+func NewMemoryBloomFilter(expectedItems uint64, falsePositiveRate float64) *MemoryBloomFilter {
+	m, k := bloomSize(expectedItems, falsePositiveRate)
+	return &MemoryBloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Add adds the key to the filter.
+func (f *MemoryBloomFilter) Add(ctx context.Context, key string) error {
+	locations := bloomLocations(key, f.m, f.k)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, l := range locations {
+		f.bits[l/64] |= 1 << (l % 64)
+	}
+	return nil
+}
+
+// Exists reports whether the key may have been added, false means it was definitely not added.
+func (f *MemoryBloomFilter) Exists(ctx context.Context, key string) bool {
+	locations := bloomLocations(key, f.m, f.k)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, l := range locations {
+		if f.bits[l/64]&(1<<(l%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reset removes all keys from the filter.
+func (f *MemoryBloomFilter) Reset(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.bits)
+	return nil
+}
+
+// RedisBloomFilter 基于 Redis bitmap 的布隆过滤器，多个实例共享同一个 key
+type RedisBloomFilter struct {
+	cmd redis.Cmdable
+	key string
+	m   uint64
+	k   uint64
+}
+
+func NewRedisBloomFilter(cmd redis.Cmdable, key string, expectedItems uint64, falsePositiveRate float64) *RedisBloomFilter {
+	m, k := bloomSize(expectedItems, falsePositiveRate)
+	return &RedisBloomFilter{
+		cmd: cmd,
+		key: key,
+		m:   m,
+		k:   k,
+	}
+}
+
+// Add adds the key to the filter.
+func (f *RedisBloomFilter) Add(ctx context.Context, key string) error {
+	return f.cmd.Eval(ctx, luaBloomAdd, []string{f.key}, f.args(key)...).Err()
+}
+
+// Exists reports whether the key may have been added.
+// Redis 出错时返回 true，让请求继续查询数据源，而不是把存在的数据当成不存在
+func (f *RedisBloomFilter) Exists(ctx context.Context, key string) bool {
+	res, err := f.cmd.Eval(ctx, luaBloomExists, []string{f.key}, f.args(key)...).Int64()
+	if err != nil {
+		slog.Error("redis bloom filter: exists error", slog.String("key", key), slog.String("error", err.Error()))
+		return true
+	}
+	return res == 1
+}
+
+// Reset removes all keys from the filter.
+func (f *RedisBloomFilter) Reset(ctx context.Context) error {
+	return f.cmd.Del(ctx, f.key).Err()
+}
+
+func (f *RedisBloomFilter) args(key string) []any {
+	locations := bloomLocations(key, f.m, f.k)
+	args := make([]any, len(locations))
+	for i, l := range locations {
+		args[i] = l
+	}
+	return args
+}
@@ -2,34 +2,48 @@ package _cache
 
 import (
 	"context"
+	"fmt"
 	"time"
-
-	"golang.org/x/sync/singleflight"
 )
 
 type BloomFilterCache struct {
 	ReadThroughCache
+	filter BloomFilter
 }
 
 func NewBloomFilterCache(cache Cache, filter BloomFilter,
 	loadFunc func(ctx context.Context, key string) ([]byte, error), expiration time.Duration,
 ) *BloomFilterCache {
-	g := &singleflight.Group{}
 	return &BloomFilterCache{
 		ReadThroughCache: ReadThroughCache{
 			Cache: cache,
 			LoadFunc: func(ctx context.Context, key string) ([]byte, error) {
 				if !filter.Exists(ctx, key) {
-					return nil, errKeyNotFound
+					return nil, fmt.Errorf("bloom filter cache: %w, key: %s", ErrKeyNotFound, key)
 				}
 				return loadFunc(ctx, key)
 			},
 			Expiration: expiration,
-			g: g,
 		},
+		filter: filter,
+	}
+}
+
+// Set sets the value for the given key and registers the key in the bloom filter.
+func (c *BloomFilterCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if err := c.Cache.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	if err := c.filter.Add(ctx, key); err != nil {
+		return fmt.Errorf("bloom filter cache: add key error: %w, key: %s", err, key)
 	}
+	return nil
 }
 
+// BloomFilter 的实现见 MemoryBloomFilter 与 RedisBloomFilter
 type BloomFilter interface {
+	// Exists 返回 false 代表 key 一定不存在，返回 true 代表 key 可能存在
 	Exists(ctx context.Context, key string) bool
+	// Add 将 key 加入过滤器，写入数据源的同时应该调用
+	Add(ctx context.Context, key string) error
 }
@@ -0,0 +1,101 @@
+package _cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomSize(t *testing.T) {
+	m, k := bloomSize(1000, 0.01)
+	assert.Equal(t, uint64(9586), m)
+	assert.Equal(t, uint64(7), k)
+}
+
+func TestBloomFilter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	tests := []struct {
+		name   string
+		filter interface {
+			BloomFilter
+			Reset(ctx context.Context) error
+		}
+	}{
+		{name: "memory", filter: NewMemoryBloomFilter(1000, 0.01)},
+		{name: "redis", filter: NewRedisBloomFilter(client, "bloom", 1000, 0.01)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := 0; i < 1000; i++ {
+				require.NoError(t, tt.filter.Add(ctx, "key"+strconv.Itoa(i)))
+			}
+			// 不会漏判
+			for i := 0; i < 1000; i++ {
+				assert.True(t, tt.filter.Exists(ctx, "key"+strconv.Itoa(i)))
+			}
+			// 误判率接近预期
+			falsePositive := 0
+			for i := 0; i < 1000; i++ {
+				if tt.filter.Exists(ctx, "other"+strconv.Itoa(i)) {
+					falsePositive++
+				}
+			}
+			assert.Less(t, falsePositive, 50)
+
+			require.NoError(t, tt.filter.Reset(ctx))
+			assert.False(t, tt.filter.Exists(ctx, "key1"))
+		})
+	}
+}
+
+func TestRedisBloomFilter_Shared(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	// 不同实例使用同一个 key 共享过滤器
+	a := NewRedisBloomFilter(client, "bloom", 100, 0.01)
+	b := NewRedisBloomFilter(client, "bloom", 100, 0.01)
+	require.NoError(t, a.Add(ctx, "key"))
+	assert.True(t, b.Exists(ctx, "key"))
+
+	// Redis 不可用时认为可能存在
+	mr.Close()
+	assert.True(t, b.Exists(ctx, "not-exist"))
+}
+
+func TestBloomFilterCache_Set(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	filter := NewMemoryBloomFilter(100, 0.01)
+	loads := 0
+	c := NewBloomFilterCache(local, filter, func(ctx context.Context, key string) ([]byte, error) {
+		loads++
+		return []byte("db"), nil
+	}, time.Minute)
+	ctx := context.Background()
+
+	_, err := c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, 0, loads)
+
+	// Set 将 key 加入过滤器，缓存过期之后可以从数据源加载
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+	assert.True(t, filter.Exists(ctx, "key"))
+	require.NoError(t, local.Delete(ctx, "key"))
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("db"), val)
+	assert.Equal(t, 1, loads)
+}
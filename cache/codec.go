@@ -0,0 +1,96 @@
+package _cache
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Codec 负责将结构体与缓存中的 []byte 相互转换
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	_ Codec = JSONCodec{}
+	_ Codec = GobCodec{}
+	_ Codec = BinaryCodec{}
+)
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// BinaryCodec 适用于自带二进制编解码的类型
+// 支持 protobuf 风格的 Marshal() ([]byte, error) / Unmarshal([]byte) error（gogo、vtproto 生成的代码），
+// 以及 encoding.BinaryMarshaler / encoding.BinaryUnmarshaler
+type BinaryCodec struct{}
+
+type protoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+type protoUnmarshaler interface {
+	Unmarshal(data []byte) error
+}
+
+func (BinaryCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case protoMarshaler:
+		return m.Marshal()
+	case encoding.BinaryMarshaler:
+		return m.MarshalBinary()
+	}
+	return nil, fmt.Errorf("binary codec: %T does not implement Marshal() ([]byte, error)", v)
+}
+
+func (BinaryCodec) Unmarshal(data []byte, v any) error {
+	if unmarshal, ok := binaryUnmarshaler(v); ok {
+		return unmarshal(data)
+	}
+	// v 为 **T 时（例如 TypedCache[*User]），先分配 *T
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && rv.Elem().Kind() == reflect.Pointer {
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		if unmarshal, ok := binaryUnmarshaler(rv.Elem().Interface()); ok {
+			return unmarshal(data)
+		}
+	}
+	return fmt.Errorf("binary codec: %T does not implement Unmarshal([]byte) error", v)
+}
+
+func binaryUnmarshaler(v any) (func(data []byte) error, bool) {
+	switch u := v.(type) {
+	case protoUnmarshaler:
+		return u.Unmarshal, true
+	case encoding.BinaryUnmarshaler:
+		return u.UnmarshalBinary, true
+	}
+	return nil, false
+}
@@ -0,0 +1,22 @@
+package _cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 所有缓存实现与装饰器返回的错误都会包装以下错误之一，调用方使用 errors.Is 判断
+var (
+	// ErrKeyNotFound key 不存在或者已经过期
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrOverCapacity 缓存已满且无法腾出空间
+	ErrOverCapacity = errors.New("over capacity")
+	// ErrCacheClosed 缓存已经关闭
+	ErrCacheClosed = errors.New("cache closed")
+	// ErrValueTooLarge 单个键值对超过了缓存的容量，详细信息见 ValueTooLargeError
+	ErrValueTooLarge = errors.New("value too large")
+	// ErrSnapshotCorrupted 快照格式不正确、版本不支持或者校验和不一致
+	ErrSnapshotCorrupted = errors.New("snapshot corrupted")
+	// ErrNegativeCached 命中了缓存的"不存在"结果，没有调用 LoadFunc，包装了 ErrKeyNotFound
+	ErrNegativeCached = fmt.Errorf("negative cached: %w", ErrKeyNotFound)
+)
@@ -0,0 +1,200 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/cache/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  func(t *testing.T, ctrl *gomock.Controller) error
+		wantErr error
+	}{
+		{
+			name: "local cache key not found",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewLocalCache(time.Minute)
+				defer c.Close()
+				_, err := c.Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "local cache load and delete key not found",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewLocalCache(time.Minute)
+				defer c.Close()
+				_, err := c.LoadAndDelete(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "local cache get after close",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewLocalCache(time.Minute)
+				require.NoError(t, c.Close())
+				_, err := c.Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrCacheClosed,
+		},
+		{
+			name: "local cache set after close",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewLocalCache(time.Minute)
+				require.NoError(t, c.Close())
+				return c.Set(context.Background(), "key", []byte("value"), 0)
+			},
+			wantErr: ErrCacheClosed,
+		},
+		{
+			name: "local cache close twice",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewLocalCache(time.Minute)
+				require.NoError(t, c.Close())
+				return c.Close()
+			},
+			wantErr: ErrCacheClosed,
+		},
+		{
+			name: "sharded cache key not found",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewShardedCache(4, time.Minute)
+				defer c.Close()
+				_, err := c.Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "sharded cache close twice",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewShardedCache(4, time.Minute)
+				require.NoError(t, c.Close())
+				return c.Close()
+			},
+			wantErr: ErrCacheClosed,
+		},
+		{
+			name: "lru key not found",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				_, err := NewLRU(1).Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "redis cache key not found",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewStringCmd(context.Background())
+				res.SetErr(redis.Nil)
+				cmd.EXPECT().Get(gomock.Any(), "key").Return(res)
+				_, err := NewRedisCache(cmd).Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "max cnt cache over capacity",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewMaxCntCache(0, NewLocalCache(time.Minute))
+				defer c.Close()
+				return c.Set(context.Background(), "key", []byte("value"), 0)
+			},
+			wantErr: ErrOverCapacity,
+		},
+		{
+			name: "max cnt cache set after close",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				c := NewMaxCntCache(1, NewLocalCache(time.Minute))
+				require.NoError(t, c.Close())
+				return c.Set(context.Background(), "key", []byte("value"), 0)
+			},
+			wantErr: ErrCacheClosed,
+		},
+		{
+			name: "max mem cache value too large",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				local := NewLocalCache(time.Minute)
+				defer local.Close()
+				return NewMaxMemCache(4, local).Set(context.Background(), "key", []byte("value"), 0)
+			},
+			wantErr: ErrValueTooLarge,
+		},
+		{
+			name: "read through cache load key not found",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				local := NewLocalCache(time.Minute)
+				defer local.Close()
+				c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+					return nil, ErrKeyNotFound
+				}, time.Minute)
+				_, err := c.Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "bloom filter cache key filtered",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				local := NewLocalCache(time.Minute)
+				defer local.Close()
+				c := NewBloomFilterCache(local, emptyBloomFilter{}, func(ctx context.Context, key string) ([]byte, error) {
+					return []byte("value"), nil
+				}, time.Minute)
+				_, err := c.Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "typed cache key not found",
+			action: func(t *testing.T, ctrl *gomock.Controller) error {
+				local := NewLocalCache(time.Minute)
+				defer local.Close()
+				_, err := NewTypedCache[string](local, JSONCodec{}).Get(context.Background(), "key")
+				return err
+			},
+			wantErr: ErrKeyNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			err := tt.action(t, ctrl)
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v, want %v", err, tt.wantErr)
+		})
+	}
+}
+
+func TestValueTooLargeError(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	err := NewMaxMemCache(4, local).Set(context.Background(), "key", []byte("value"), 0)
+	var tooLarge *ValueTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, &ValueTooLargeError{Key: "key", Size: 8, Max: 4}, tooLarge)
+}
+
+type emptyBloomFilter struct{}
+
+func (emptyBloomFilter) Exists(ctx context.Context, key string) bool {
+	return false
+}
+
+func (emptyBloomFilter) Add(ctx context.Context, key string) error {
+	return nil
+}
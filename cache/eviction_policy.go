@@ -0,0 +1,100 @@
+package _cache
+
+import "container/list"
+
+// EvictionPolicy 决定缓存满了之后淘汰哪一个 key
+// 实现不需要保证并发安全，由使用方加锁
+type EvictionPolicy interface {
+	// Add 记录一个新写入的 key
+	Add(key string)
+	// Access 记录一次对已有 key 的访问（读或者覆盖写）
+	Access(key string)
+	// Remove 将 key 从策略中移除（主动删除、过期或者被淘汰）
+	Remove(key string)
+	// Victim 返回下一个需要被淘汰的 key，没有可淘汰的 key 时返回 false
+	Victim() (string, bool)
+}
+
+var (
+	_ EvictionPolicy = &LRUPolicy{}
+	_ EvictionPolicy = &FIFOPolicy{}
+)
+
+// LRUPolicy 淘汰最久未被访问的 key
+type LRUPolicy struct {
+	ll    *list.List // front 为最近访问
+	index map[string]*list.Element
+}
+
+func NewLRUPolicy() *LRUPolicy {
+	return &LRUPolicy{
+		ll:    list.New(),
+		index: make(map[string]*list.Element),
+	}
+}
+
+func (p *LRUPolicy) Add(key string) {
+	if e, ok := p.index[key]; ok {
+		p.ll.MoveToFront(e)
+		return
+	}
+	p.index[key] = p.ll.PushFront(key)
+}
+
+func (p *LRUPolicy) Access(key string) {
+	if e, ok := p.index[key]; ok {
+		p.ll.MoveToFront(e)
+	}
+}
+
+func (p *LRUPolicy) Remove(key string) {
+	if e, ok := p.index[key]; ok {
+		p.ll.Remove(e)
+		delete(p.index, key)
+	}
+}
+
+func (p *LRUPolicy) Victim() (string, bool) {
+	e := p.ll.Back()
+	if e == nil {
+		return "", false
+	}
+	return e.Value.(string), true
+}
+
+// FIFOPolicy 按照写入顺序淘汰，访问不会改变顺序
+type FIFOPolicy struct {
+	ll    *list.List // front 为最早写入
+	index map[string]*list.Element
+}
+
+func NewFIFOPolicy() *FIFOPolicy {
+	return &FIFOPolicy{
+		ll:    list.New(),
+		index: make(map[string]*list.Element),
+	}
+}
+
+func (p *FIFOPolicy) Add(key string) {
+	if _, ok := p.index[key]; ok {
+		return
+	}
+	p.index[key] = p.ll.PushBack(key)
+}
+
+func (p *FIFOPolicy) Access(key string) {}
+
+func (p *FIFOPolicy) Remove(key string) {
+	if e, ok := p.index[key]; ok {
+		p.ll.Remove(e)
+		delete(p.index, key)
+	}
+}
+
+func (p *FIFOPolicy) Victim() (string, bool) {
+	e := p.ll.Front()
+	if e == nil {
+		return "", false
+	}
+	return e.Value.(string), true
+}
@@ -0,0 +1,123 @@
+package _cache
+
+import "container/list"
+
+var _ EvictionPolicy = &ARCPolicy{}
+
+// ARCPolicy Adaptive Replacement Cache
+// t1 保存只访问过一次的 key，t2 保存访问过多次的 key
+// b1、b2 分别是从 t1、t2 淘汰出去的 key（只保留 key，不保留值），
+// 命中 b1 说明 t1 太小，命中 b2 说明 t2 太小，p 为 t1 的目标大小，据此自适应调整
+type ARCPolicy struct {
+	capacity int
+	p        int
+	t1, t2   *list.List
+	b1, b2   *list.List
+	index    map[string]*list.Element
+}
+
+type arcEntry struct {
+	key string
+	in  *list.List // 当前所在的链表
+}
+
+// NewARCPolicy capacity 需要与缓存的容量保持一致
+func NewARCPolicy(capacity int) *ARCPolicy {
+	return &ARCPolicy{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		index:    make(map[string]*list.Element),
+	}
+}
+
+func (p *ARCPolicy) Add(key string) {
+	e, ok := p.index[key]
+	if !ok {
+		p.push(p.t1, key)
+		p.trimGhost()
+		return
+	}
+	entry := e.Value.(*arcEntry)
+	switch entry.in {
+	case p.t1, p.t2:
+		p.Access(key)
+	case p.b1:
+		// 命中 b1，扩大 t1 的目标大小
+		p.p = min(p.capacity, p.p+max(p.b2.Len()/p.b1.Len(), 1))
+		p.move(e, p.t2)
+	case p.b2:
+		// 命中 b2，缩小 t1 的目标大小
+		p.p = max(0, p.p-max(p.b1.Len()/p.b2.Len(), 1))
+		p.move(e, p.t2)
+	}
+}
+
+func (p *ARCPolicy) Access(key string) {
+	e, ok := p.index[key]
+	if !ok {
+		return
+	}
+	switch e.Value.(*arcEntry).in {
+	case p.t1, p.t2:
+		// 第二次访问之后进入 t2
+		p.move(e, p.t2)
+	}
+}
+
+func (p *ARCPolicy) Remove(key string) {
+	e, ok := p.index[key]
+	if !ok {
+		return
+	}
+	entry := e.Value.(*arcEntry)
+	// 被淘汰的 key 已经在 Victim 中移入了 b1/b2，这里只处理主动删除
+	if entry.in == p.t1 || entry.in == p.t2 {
+		entry.in.Remove(e)
+		delete(p.index, key)
+	}
+}
+
+// Victim 选出的 key 会被移入 b1/b2，调用方必须将其从缓存中删除
+func (p *ARCPolicy) Victim() (string, bool) {
+	var e *list.Element
+	if p.t1.Len() > 0 && (p.t1.Len() > p.p || p.t2.Len() == 0) {
+		e = p.t1.Back()
+		p.move(e, p.b1)
+	} else if p.t2.Len() > 0 {
+		e = p.t2.Back()
+		p.move(e, p.b2)
+	} else {
+		return "", false
+	}
+	p.trimGhost()
+	return e.Value.(*arcEntry).key, true
+}
+
+func (p *ARCPolicy) push(l *list.List, key string) {
+	p.index[key] = l.PushFront(&arcEntry{key: key, in: l})
+}
+
+func (p *ARCPolicy) move(e *list.Element, to *list.List) {
+	entry := e.Value.(*arcEntry)
+	entry.in.Remove(e)
+	p.push(to, entry.key)
+}
+
+// trimGhost 保证 |t1|+|b1| <= c 且总大小 <= 2c
+func (p *ARCPolicy) trimGhost() {
+	for p.t1.Len()+p.b1.Len() > p.capacity && p.b1.Len() > 0 {
+		p.dropGhost(p.b1)
+	}
+	for p.t1.Len()+p.t2.Len()+p.b1.Len()+p.b2.Len() > 2*p.capacity && p.b2.Len() > 0 {
+		p.dropGhost(p.b2)
+	}
+}
+
+func (p *ARCPolicy) dropGhost(l *list.List) {
+	e := l.Back()
+	l.Remove(e)
+	delete(p.index, e.Value.(*arcEntry).key)
+}
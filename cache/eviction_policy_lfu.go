@@ -0,0 +1,97 @@
+package _cache
+
+import "container/list"
+
+var _ EvictionPolicy = &LFUPolicy{}
+
+// LFUPolicy 淘汰访问次数最少的 key，次数相同时淘汰最久未访问的
+// 所有操作均为 O(1)：按访问次数分桶，并记录当前最小次数
+type LFUPolicy struct {
+	index   map[string]*list.Element
+	buckets map[int]*list.List // 访问次数 -> 该次数下的 key，front 为最近访问
+	minFreq int
+}
+
+type lfuEntry struct {
+	key  string
+	freq int
+}
+
+func NewLFUPolicy() *LFUPolicy {
+	return &LFUPolicy{
+		index:   make(map[string]*list.Element),
+		buckets: make(map[int]*list.List),
+	}
+}
+
+func (p *LFUPolicy) Add(key string) {
+	if _, ok := p.index[key]; ok {
+		p.Access(key)
+		return
+	}
+	p.index[key] = p.bucket(1).PushFront(&lfuEntry{key: key, freq: 1})
+	p.minFreq = 1
+}
+
+func (p *LFUPolicy) Access(key string) {
+	e, ok := p.index[key]
+	if !ok {
+		return
+	}
+	entry := e.Value.(*lfuEntry)
+	p.unlink(e)
+	if p.minFreq == entry.freq && p.buckets[entry.freq] == nil {
+		p.minFreq++
+	}
+	entry.freq++
+	p.index[key] = p.bucket(entry.freq).PushFront(entry)
+}
+
+func (p *LFUPolicy) Remove(key string) {
+	e, ok := p.index[key]
+	if !ok {
+		return
+	}
+	p.unlink(e)
+	delete(p.index, key)
+	if len(p.index) == 0 {
+		p.minFreq = 0
+		return
+	}
+	// minFreq 对应的桶被删空了，重新找最小的访问次数
+	if p.buckets[p.minFreq] == nil {
+		p.minFreq = 0
+		for freq := range p.buckets {
+			if p.minFreq == 0 || freq < p.minFreq {
+				p.minFreq = freq
+			}
+		}
+	}
+}
+
+func (p *LFUPolicy) Victim() (string, bool) {
+	l := p.buckets[p.minFreq]
+	if l == nil {
+		return "", false
+	}
+	return l.Back().Value.(*lfuEntry).key, true
+}
+
+func (p *LFUPolicy) bucket(freq int) *list.List {
+	l, ok := p.buckets[freq]
+	if !ok {
+		l = list.New()
+		p.buckets[freq] = l
+	}
+	return l
+}
+
+// unlink 将元素从所在的桶中摘除，桶空了就一起删除
+func (p *LFUPolicy) unlink(e *list.Element) {
+	freq := e.Value.(*lfuEntry).freq
+	l := p.buckets[freq]
+	l.Remove(e)
+	if l.Len() == 0 {
+		delete(p.buckets, freq)
+	}
+}
@@ -0,0 +1,131 @@
+package _cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictionPolicy_Victim(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy func() EvictionPolicy
+		ops    func(p EvictionPolicy)
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "lru empty",
+			policy: func() EvictionPolicy { return NewLRUPolicy() },
+			ops:    func(p EvictionPolicy) {},
+		},
+		{
+			name:   "lru least recently used",
+			policy: func() EvictionPolicy { return NewLRUPolicy() },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				p.Add("key3")
+				p.Access("key1")
+			},
+			want:   "key2",
+			wantOk: true,
+		},
+		{
+			name:   "lru removed",
+			policy: func() EvictionPolicy { return NewLRUPolicy() },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				p.Remove("key1")
+			},
+			want:   "key2",
+			wantOk: true,
+		},
+		{
+			name:   "fifo ignores access",
+			policy: func() EvictionPolicy { return NewFIFOPolicy() },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				p.Access("key1")
+			},
+			want:   "key1",
+			wantOk: true,
+		},
+		{
+			name:   "lfu least frequently used",
+			policy: func() EvictionPolicy { return NewLFUPolicy() },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				p.Add("key3")
+				p.Access("key1")
+				p.Access("key1")
+				p.Access("key3")
+			},
+			want:   "key2",
+			wantOk: true,
+		},
+		{
+			name:   "lfu same frequency evicts least recent",
+			policy: func() EvictionPolicy { return NewLFUPolicy() },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				p.Access("key2")
+				p.Access("key1")
+			},
+			want:   "key2",
+			wantOk: true,
+		},
+		{
+			name:   "lfu min frequency removed",
+			policy: func() EvictionPolicy { return NewLFUPolicy() },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				p.Access("key2")
+				p.Access("key2")
+				p.Access("key1")
+				p.Remove("key1")
+			},
+			want:   "key2",
+			wantOk: true,
+		},
+		{
+			name:   "arc evicts from t1 first",
+			policy: func() EvictionPolicy { return NewARCPolicy(3) },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				p.Access("key1")
+			},
+			want:   "key2",
+			wantOk: true,
+		},
+		{
+			name:   "arc ghost hit goes to t2",
+			policy: func() EvictionPolicy { return NewARCPolicy(2) },
+			ops: func(p EvictionPolicy) {
+				p.Add("key1")
+				p.Add("key2")
+				victim, _ := p.Victim() // key1 -> b1
+				p.Remove(victim)
+				p.Add("key1") // 命中 b1，p 增大到 1，key1 进入 t2
+			},
+			// t1 = [key2] 没有超过目标大小，从 t2 淘汰
+			want:   "key1",
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.policy()
+			tt.ops(p)
+			key, ok := p.Victim()
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+}
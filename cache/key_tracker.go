@@ -0,0 +1,60 @@
+package _cache
+
+import "sync"
+
+// keyTracker 记录通过装饰器写入的 key 的附加信息，RefreshAheadCache 与 XFetchCache 用它记录过期时间
+// Cache 接口拿不到剩余过期时间，所以只能在写入时记录，并在 key 过期、删除或者被淘汰时停止跟踪
+// 不会触发 OnEvicted 的缓存（例如 redis 中过期的 key）需要在未命中时调用 untrack
+type keyTracker[T any] struct {
+	mu      sync.Mutex
+	entries map[string]T
+	evict   func(key string, value []byte)
+}
+
+// watch 接管 cache 的 OnEvicted 回调，之后需要监听淘汰事件只能通过 keyTracker.OnEvicted
+func (t *keyTracker[T]) watch(cache Cache) {
+	t.entries = make(map[string]T)
+	cache.OnEvicted(t.onEvicted)
+}
+
+// OnEvicted sets the callback function which is called when a key is deleted, expired or evicted.
+func (t *keyTracker[T]) OnEvicted(fn func(key string, value []byte)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.evict = fn
+}
+
+func (t *keyTracker[T]) tracked(key string) (T, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	return entry, ok
+}
+
+// update 在持有锁时以 key 当前的记录（没有时为零值）调用 fn，fn 返回 false 时停止跟踪
+func (t *keyTracker[T]) update(key string, fn func(entry T) (T, bool)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, keep := fn(t.entries[key])
+	if !keep {
+		delete(t.entries, key)
+		return
+	}
+	t.entries[key] = entry
+}
+
+func (t *keyTracker[T]) untrack(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+func (t *keyTracker[T]) onEvicted(key string, value []byte) {
+	t.mu.Lock()
+	delete(t.entries, key)
+	evict := t.evict
+	t.mu.Unlock()
+	if evict != nil {
+		evict(key, value)
+	}
+}
@@ -0,0 +1,47 @@
+package _cache
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+const keyVersionShards = 256
+
+// keyVersions 按 key 分段的版本号，每段一把锁
+// 修改数据的一方在持有锁时增加版本号，回填缓存的一方在持有锁时确认版本号没有变化，两者不会交错
+// 不同的 key 可能落在同一段，只会导致多余的版本冲突（少回填一次），不影响正确性
+type keyVersions struct {
+	seed   maphash.Seed
+	shards [keyVersionShards]keyVersionShard
+}
+
+type keyVersionShard struct {
+	mu      sync.Mutex
+	version uint64
+}
+
+func newKeyVersions() *keyVersions {
+	return &keyVersions{seed: maphash.MakeSeed()}
+}
+
+// load 返回 key 当前的版本号
+func (v *keyVersions) load(key string) uint64 {
+	s := v.lock(key)
+	defer s.unlock()
+	return s.version
+}
+
+// lock 锁住 key 所在的段，调用方需要调用 unlock
+func (v *keyVersions) lock(key string) *keyVersionShard {
+	s := v.shard(key)
+	s.mu.Lock()
+	return s
+}
+
+func (v *keyVersions) shard(key string) *keyVersionShard {
+	return &v.shards[maphash.String(v.seed, key)%keyVersionShards]
+}
+
+func (s *keyVersionShard) unlock() {
+	s.mu.Unlock()
+}
@@ -1,19 +1,20 @@
 package _cache
 
 import (
+	"container/heap"
 	"context"
-	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
-)
 
-var (
-	errKeyNotFound = errors.New("key not found")
+	"github.com/LXJ0000/go-combat/clock"
 )
 
 const cleanCount = 1000
 
+var _ BatchCache = &LocalCache{}
+
 type LocalCacheOption func(*LocalCache)
 
 func WithEvict(evict func(key string, value []byte)) LocalCacheOption {
@@ -22,77 +23,129 @@ func WithEvict(evict func(key string, value []byte)) LocalCacheOption {
 	}
 }
 
+// WithClock 指定时钟，测试中可以传入 clock.FakeClock 控制过期
+func WithClock(clock clock.Clock) LocalCacheOption {
+	return func(c *LocalCache) {
+		c.clock = clock
+	}
+}
+
 type LocalCache struct {
-	data  map[string]*item
-	mu    sync.RWMutex
-	close chan struct{}
-	evict func(key string, value []byte) // evict callback function
+	data   map[string]*item
+	tags   map[string]map[string]struct{} // tag -> keys
+	expiry expiryHeap                     // 按照 deadline 排序的最小堆，只包含设置了过期时间的 item
+	mu     sync.RWMutex
+	close  chan struct{}
+	closed bool
+	evict  func(key string, value []byte) // evict callback function
+	clock  clock.Clock
+
+	snapshotPath     string
+	snapshotInterval time.Duration
 }
 
 type item struct {
+	key      string
 	value    []byte
 	deadline time.Time
+	index    int // 在 expiry 中的下标，-1 表示不在堆中
+	tags     []string
 }
 
 // NewLocalCache creates a new LocalCache with the given interval for cleaning up expired items.
 func NewLocalCache(interval time.Duration, opts ...LocalCacheOption) *LocalCache {
 	cache := &LocalCache{
 		data:  make(map[string]*item),
+		tags:  make(map[string]map[string]struct{}),
 		close: make(chan struct{}),
+		clock: clock.New(),
+	}
+	for _, opt := range opts {
+		opt(cache)
 	}
+
+	if cache.snapshotPath != "" {
+		if err := cache.RestoreFromFile(cache.snapshotPath); err != nil {
+			slog.Error("local cache: restore snapshot error", slog.String("path", cache.snapshotPath), slog.String("error", err.Error()))
+		}
+		if cache.snapshotInterval > 0 {
+			// 写快照可能很慢，使用单独的 goroutine，避免阻塞过期清理
+			st := cache.clock.NewTicker(cache.snapshotInterval)
+			go func() {
+				defer st.Stop()
+				for {
+					select {
+					case <-cache.close:
+						return
+					case <-st.C():
+						cache.snapshot()
+					}
+				}
+			}()
+		}
+	}
+
 	// start a goroutine to clean up expired items every interval
+	t := cache.clock.NewTicker(interval)
 	go func() {
-		t := time.NewTicker(interval)
+		defer t.Stop()
 		for {
 			select {
 			case <-cache.close:
 				return
-			case now := <-t.C:
-				cache.mu.Lock()
-				i := 0 // count the number of items cleaned up
-				for k, v := range cache.data {
-					if !v.deadline.IsZero() && now.After(v.deadline) {
-						cache.delete(k)
-					}
-					i++
-					if i > cleanCount {
-						break
-					}
-				}
-				cache.mu.Unlock()
+			case <-t.C():
+				cache.clean(cache.clock.Now())
 			}
 		}
 	}()
 
-	for _, opt := range opts {
-		opt(cache)
-	}
-
 	return cache
 }
 
+// clean 删除所有在 now 之前过期的 item
+// 每删除 cleanCount 个释放一次锁，避免长时间阻塞读写
+func (c *LocalCache) clean(now time.Time) {
+	for {
+		c.mu.Lock()
+		i := 0 // count the number of items cleaned up
+		for len(c.expiry) > 0 && now.After(c.expiry[0].deadline) && i < cleanCount {
+			c.delete(c.expiry[0].key)
+			i++
+		}
+		done := len(c.expiry) == 0 || !now.After(c.expiry[0].deadline)
+		c.mu.Unlock()
+		if done {
+			return
+		}
+	}
+}
+
 // Get returns the value for the given key.
 func (c *LocalCache) Get(ctx context.Context, key string) ([]byte, error) {
 	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		return nil, fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
 	item, ok := c.data[key]
 	c.mu.RUnlock()
 	if !ok {
-		// return nil, errKeyNotFound
-		return nil, fmt.Errorf("local cache: %w, key: %s", errKeyNotFound, key)
+		// return nil, ErrKeyNotFound
+		return nil, fmt.Errorf("local cache: %w, key: %s", ErrKeyNotFound, key)
 	}
 	// check if the item is expired
-	now := time.Now()
+	now := c.clock.Now()
 	if !item.deadline.IsZero() && now.After(item.deadline) {
 		c.mu.Lock()
 		defer c.mu.Unlock()
 		item, ok = c.data[key]
 		if !ok {
-			// return nil, errKeyNotFound
-			return nil, fmt.Errorf("local cache: %w, key: %s", errKeyNotFound, key)
+			// return nil, ErrKeyNotFound
+			return nil, fmt.Errorf("local cache: %w, key: %s", ErrKeyNotFound, key)
 		}
 		if !item.deadline.IsZero() && now.After(item.deadline) { // double check
 			c.delete(key)
-			return nil, fmt.Errorf("local cache: %w, key: %s", errKeyNotFound, key)
+			return nil, fmt.Errorf("local cache: %w, key: %s", ErrKeyNotFound, key)
 		}
 	}
 	return item.value, nil
@@ -103,17 +156,46 @@ func (c *LocalCache) Get(ctx context.Context, key string) ([]byte, error) {
 func (c *LocalCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
 	return c.set(key, value, expiration)
 }
 
 func (c *LocalCache) set(key string, value []byte, expiration time.Duration) error {
+	return c.setWithTags(key, value, expiration, nil)
+}
+
+// setWithTags 覆盖写时旧值的 tags 会被清除
+func (c *LocalCache) setWithTags(key string, value []byte, expiration time.Duration, tags []string) error {
 	var deadline time.Time
 	if expiration != 0 {
-		deadline = time.Now().Add(expiration)
+		deadline = c.clock.Now().Add(expiration)
 	}
-	c.data[key] = &item{
+	if old, ok := c.data[key]; ok {
+		if old.index >= 0 {
+			heap.Remove(&c.expiry, old.index)
+		}
+		c.untag(old)
+	}
+	it := &item{
+		key:      key,
 		value:    value,
 		deadline: deadline,
+		index:    -1,
+		tags:     tags,
+	}
+	c.data[key] = it
+	if !deadline.IsZero() {
+		heap.Push(&c.expiry, it)
+	}
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
 	}
 	return nil
 }
@@ -122,6 +204,9 @@ func (c *LocalCache) set(key string, value []byte, expiration time.Duration) err
 func (c *LocalCache) Delete(ctx context.Context, key string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
 	c.delete(key)
 	return nil
 }
@@ -130,6 +215,9 @@ func (c *LocalCache) Delete(ctx context.Context, key string) error {
 func (c *LocalCache) Exists(ctx context.Context, key string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	if c.closed {
+		return false
+	}
 	_, ok := c.data[key]
 	return ok
 }
@@ -138,22 +226,86 @@ func (c *LocalCache) Exists(ctx context.Context, key string) bool {
 func (c *LocalCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.closed {
+		return nil, fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
 	item, ok := c.data[key]
 	if !ok {
-		return nil, fmt.Errorf("local cache: %w, key: %s", errKeyNotFound, key)
+		return nil, fmt.Errorf("local cache: %w, key: %s", ErrKeyNotFound, key)
 	}
 	c.delete(key)
 	return item.value, nil
 }
 
-// Close closes the cache.
+// MGet returns the values of the keys that exist under a single lock.
+func (c *LocalCache) MGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	// 过期的 key 需要顺便删除，所以直接加写锁
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
+	now := c.clock.Now()
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		item, ok := c.data[key]
+		if !ok {
+			continue
+		}
+		if !item.deadline.IsZero() && now.After(item.deadline) {
+			c.delete(key)
+			continue
+		}
+		values[key] = item.value
+	}
+	return values, nil
+}
+
+// MSet sets all entries under a single lock.
+func (c *LocalCache) MSet(ctx context.Context, entries map[string][]byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
+	for key, value := range entries {
+		if err := c.set(key, value, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MDelete deletes the values for the given keys under a single lock.
+func (c *LocalCache) MDelete(ctx context.Context, keys []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
+	for _, key := range keys {
+		c.delete(key)
+	}
+	return nil
+}
+
+// OnEvicted sets the callback function which is called when a key is deleted or expired.
+func (c *LocalCache) OnEvicted(fn func(key string, value []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict = fn
+}
+
+// Close stops the cleanup goroutine, after which reads and writes return ErrCacheClosed.
 func (c *LocalCache) Close() error {
-	select {
-	case c.close <- struct{}{}:
-		return nil
-	default:
-		return errors.New("cache is already closed")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("local cache: %w", ErrCacheClosed)
 	}
+	c.closed = true
+	close(c.close)
+	return nil
 }
 
 func (c *LocalCache) delete(key string) {
@@ -162,6 +314,10 @@ func (c *LocalCache) delete(key string) {
 		return
 	}
 	delete(c.data, key)
+	if item.index >= 0 {
+		heap.Remove(&c.expiry, item.index)
+	}
+	c.untag(item)
 	if c.evict != nil {
 		c.evict(key, item.value)
 	}
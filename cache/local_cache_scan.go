@@ -0,0 +1,89 @@
+package _cache
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Range returns an iterator over all unexpired entries in key order.
+// 迭代的是调用时的一致性快照：在读锁内复制，释放锁之后再调用 yield，yield 中可以读写缓存
+func (c *LocalCache) Range() iter.Seq2[string, []byte] {
+	return c.Scan("")
+}
+
+// Scan returns an iterator over the unexpired entries whose key has the given prefix, in key order.
+func (c *LocalCache) Scan(prefix string) iter.Seq2[string, []byte] {
+	return func(yield func(string, []byte) bool) {
+		for _, it := range c.scan(prefix) {
+			if !yield(it.key, it.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *LocalCache) scan(prefix string) []*item {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return nil
+	}
+	now := c.clock.Now()
+	items := make([]*item, 0)
+	for key, it := range c.data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if !it.deadline.IsZero() && now.After(it.deadline) {
+			continue
+		}
+		items = append(items, it)
+	}
+	slices.SortFunc(items, func(a, b *item) int {
+		return strings.Compare(a.key, b.key)
+	})
+	return items
+}
+
+// SetWithTags sets the value like Set and attaches tags to the key for InvalidateTag.
+// 覆盖写（包括 Set）会替换原来的 tags
+func (c *LocalCache) SetWithTags(ctx context.Context, key string, value []byte, expiration time.Duration, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
+	return c.setWithTags(key, value, expiration, slices.Clone(tags))
+}
+
+// InvalidateTag deletes every key carrying the tag, firing the evict callback for each, and returns the number of deleted keys.
+func (c *LocalCache) InvalidateTag(ctx context.Context, tag string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
+	keys := c.tags[tag]
+	n := len(keys)
+	// delete 会修改 c.tags[tag]，先复制一份
+	for _, key := range slices.Collect(maps.Keys(keys)) {
+		c.delete(key)
+	}
+	return n, nil
+}
+
+// untag 从 tag 索引中移除 it，需要持有写锁
+func (c *LocalCache) untag(it *item) {
+	for _, tag := range it.tags {
+		keys := c.tags[tag]
+		delete(keys, it.key)
+		if len(keys) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package _cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache_Scan(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	c := NewLocalCache(time.Hour, WithClock(fake))
+	defer c.Close()
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "user:42:profile", []byte("profile"), 0))
+	require.NoError(t, c.Set(ctx, "user:42:orders", []byte("orders"), 0))
+	require.NoError(t, c.Set(ctx, "user:42:expired", []byte("expired"), time.Second))
+	require.NoError(t, c.Set(ctx, "user:43:profile", []byte("other"), 0))
+	fake.Advance(2 * time.Second)
+
+	assert.Equal(t, map[string][]byte{
+		"user:42:orders":  []byte("orders"),
+		"user:42:profile": []byte("profile"),
+	}, maps.Collect(c.Scan("user:42:")))
+
+	assert.Equal(t, []string{"user:42:orders", "user:42:profile", "user:43:profile"}, rangeKeys(c))
+
+	// yield 中可以修改缓存，也可以提前结束
+	for key := range c.Scan("user:42:") {
+		require.NoError(t, c.Delete(ctx, key))
+		break
+	}
+	assert.Equal(t, []string{"user:42:profile", "user:43:profile"}, rangeKeys(c))
+
+	require.NoError(t, c.Close())
+	assert.Empty(t, maps.Collect(c.Range()))
+}
+
+// rangeKeys 按照迭代顺序返回所有 key
+func rangeKeys(c *LocalCache) []string {
+	var keys []string
+	for key := range c.Range() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestLocalCache_InvalidateTag(t *testing.T) {
+	c := NewLocalCache(time.Minute)
+	defer c.Close()
+	var evicted []string
+	c.OnEvicted(func(key string, value []byte) {
+		evicted = append(evicted, key)
+	})
+	ctx := context.Background()
+	require.NoError(t, c.SetWithTags(ctx, "profile", []byte("v"), 0, "user:42"))
+	require.NoError(t, c.SetWithTags(ctx, "orders", []byte("v"), 0, "user:42", "orders"))
+	require.NoError(t, c.SetWithTags(ctx, "other", []byte("v"), 0, "user:43"))
+	// 覆盖写替换 tags
+	require.NoError(t, c.SetWithTags(ctx, "moved", []byte("v"), 0, "user:42"))
+	require.NoError(t, c.Set(ctx, "moved", []byte("v"), 0))
+
+	n, err := c.InvalidateTag(ctx, "user:42")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	slices.Sort(evicted)
+	assert.Equal(t, []string{"orders", "profile"}, evicted)
+	assert.True(t, c.Exists(ctx, "other"))
+	assert.True(t, c.Exists(ctx, "moved"))
+	// 删除之后索引同步清理
+	assert.NotContains(t, c.tags, "user:42")
+	assert.NotContains(t, c.tags, "orders")
+
+	n, err = c.InvalidateTag(ctx, "not-exist")
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	require.NoError(t, c.Close())
+	_, err = c.InvalidateTag(ctx, "user:43")
+	assert.ErrorIs(t, err, ErrCacheClosed)
+	assert.ErrorIs(t, c.SetWithTags(ctx, "key", nil, 0, "tag"), ErrCacheClosed)
+}
+
+func TestMaxCntCache_SetWithTags(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := NewMaxCntCache(1, local)
+	ctx := context.Background()
+	require.NoError(t, c.SetWithTags(ctx, "key1", []byte("v"), 0, "tag"))
+	require.NoError(t, c.SetWithTags(ctx, "key2", []byte("v"), 0, "tag"))
+	assert.False(t, c.Exists(ctx, "key1"))
+	n, err := c.InvalidateTag(ctx, "tag")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int32(0), c.cnt)
+}
+
+func TestLocalCache_SnapshotTags(t *testing.T) {
+	src := NewLocalCache(time.Hour)
+	defer src.Close()
+	ctx := context.Background()
+	require.NoError(t, src.SetWithTags(ctx, "key", []byte("value"), 0, "tag1", "tag2"))
+	var buf bytes.Buffer
+	require.NoError(t, src.Snapshot(&buf))
+
+	dst := NewLocalCache(time.Hour)
+	defer dst.Close()
+	require.NoError(t, dst.Restore(&buf))
+	n, err := dst.InvalidateTag(ctx, "tag2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestLocalCache_RestoreVersion1(t *testing.T) {
+	// 版本 1 没有 tags
+	var buf bytes.Buffer
+	buf.WriteString("LCSN")
+	buf.WriteByte(1)
+	buf.WriteByte(1) // count
+	buf.WriteByte(3)
+	buf.WriteString("key")
+	buf.WriteByte(5)
+	buf.WriteString("value")
+	_ = binary.Write(&buf, binary.BigEndian, int64(0))
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+
+	c := NewLocalCache(time.Hour)
+	defer c.Close()
+	require.NoError(t, c.Restore(&buf))
+	val, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+}
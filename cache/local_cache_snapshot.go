@@ -0,0 +1,296 @@
+package _cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 快照格式（整数均为大端）：
+//
+//	magic "LCSN" | version uint8 | count uvarint
+//	count 个 entry：keyLen uvarint | key | valueLen uvarint | value | deadline int64（UnixNano，0 表示不过期）
+//	  version >= 2：tagCount uvarint | tagCount 个 tagLen uvarint | tag
+//	crc32 uint32（IEEE，覆盖前面所有字节）
+//
+// deadline 保存的是绝对时间，恢复时按照当前时间重新计算剩余 TTL，停机期间过期的 entry 会被丢弃
+const (
+	snapshotMagic   = "LCSN"
+	snapshotVersion = 2 // 版本 2 增加了 tags，依旧可以读取版本 1
+	// snapshotMaxSize 单个 key 或 value 的长度上限，避免损坏的快照导致分配过大的内存
+	snapshotMaxSize = 1 << 30
+)
+
+// WithSnapshot 启动时从 path 恢复数据，并每隔 interval 将快照写入 path
+// 写入时先写临时文件再重命名，进程中途退出也不会留下不完整的快照
+func WithSnapshot(path string, interval time.Duration) LocalCacheOption {
+	return func(c *LocalCache) {
+		c.snapshotPath = path
+		c.snapshotInterval = interval
+	}
+}
+
+// Snapshot writes all unexpired entries to w.
+func (c *LocalCache) Snapshot(w io.Writer) error {
+	c.mu.RLock()
+	now := c.clock.Now()
+	items := make([]*item, 0, len(c.data))
+	for _, it := range c.data {
+		if !it.deadline.IsZero() && now.After(it.deadline) {
+			continue
+		}
+		items = append(items, it)
+	}
+	c.mu.RUnlock()
+
+	// item 写入之后不会被修改，释放锁之后再序列化
+	bw := bufio.NewWriter(w)
+	crc := crc32.NewIEEE()
+	mw := io.MultiWriter(bw, crc)
+	buf := make([]byte, binary.MaxVarintLen64)
+	writeBytes := func(b []byte) error {
+		n := binary.PutUvarint(buf, uint64(len(b)))
+		if _, err := mw.Write(buf[:n]); err != nil {
+			return err
+		}
+		_, err := mw.Write(b)
+		return err
+	}
+
+	if _, err := mw.Write([]byte{snapshotMagic[0], snapshotMagic[1], snapshotMagic[2], snapshotMagic[3], snapshotVersion}); err != nil {
+		return err
+	}
+	n := binary.PutUvarint(buf, uint64(len(items)))
+	if _, err := mw.Write(buf[:n]); err != nil {
+		return err
+	}
+	for _, it := range items {
+		if err := writeBytes([]byte(it.key)); err != nil {
+			return err
+		}
+		if err := writeBytes(it.value); err != nil {
+			return err
+		}
+		var deadline int64
+		if !it.deadline.IsZero() {
+			deadline = it.deadline.UnixNano()
+		}
+		if err := binary.Write(mw, binary.BigEndian, deadline); err != nil {
+			return err
+		}
+		n := binary.PutUvarint(buf, uint64(len(it.tags)))
+		if _, err := mw.Write(buf[:n]); err != nil {
+			return err
+		}
+		for _, tag := range it.tags {
+			if err := writeBytes([]byte(tag)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := binary.Write(bw, binary.BigEndian, crc.Sum32()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Restore reads a snapshot written by Snapshot and sets its entries, entries that have expired are dropped.
+// 快照校验通过之后才会写入缓存，已有的同名 key 会被覆盖
+func (c *LocalCache) Restore(r io.Reader) error {
+	entries, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("local cache: %w", ErrCacheClosed)
+	}
+	now := c.clock.Now()
+	for _, e := range entries {
+		expiration, ok := e.expiration(now)
+		if !ok {
+			continue
+		}
+		if err := c.setWithTags(e.key, e.value, expiration, e.tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type snapshotEntry struct {
+	key      string
+	value    []byte
+	deadline int64
+	tags     []string
+}
+
+// expiration 返回相对 now 的剩余 TTL，已经过期时返回 false
+func (e snapshotEntry) expiration(now time.Time) (time.Duration, bool) {
+	if e.deadline == 0 {
+		return 0, true
+	}
+	expiration := time.Unix(0, e.deadline).Sub(now)
+	return expiration, expiration > 0
+}
+
+// readSnapshot 读取并校验整个快照
+func readSnapshot(r io.Reader) ([]snapshotEntry, error) {
+	crc := crc32.NewIEEE()
+	br := &snapshotReader{r: bufio.NewReader(r), crc: crc}
+
+	header := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("local cache: %w: read header: %w", ErrSnapshotCorrupted, err)
+	}
+	if !bytes.Equal(header[:len(snapshotMagic)], []byte(snapshotMagic)) {
+		return nil, fmt.Errorf("local cache: %w: bad magic", ErrSnapshotCorrupted)
+	}
+	version := header[len(snapshotMagic)]
+	if version < 1 || version > snapshotVersion {
+		return nil, fmt.Errorf("local cache: %w: unsupported version %d", ErrSnapshotCorrupted, version)
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("local cache: %w: read count: %w", ErrSnapshotCorrupted, err)
+	}
+
+	entries := make([]snapshotEntry, 0, min(count, 1024))
+	for i := uint64(0); i < count; i++ {
+		key, err := br.readBytes()
+		if err != nil {
+			return nil, fmt.Errorf("local cache: %w: read key: %w", ErrSnapshotCorrupted, err)
+		}
+		value, err := br.readBytes()
+		if err != nil {
+			return nil, fmt.Errorf("local cache: %w: read value: %w", ErrSnapshotCorrupted, err)
+		}
+		var deadline int64
+		if err := binary.Read(br, binary.BigEndian, &deadline); err != nil {
+			return nil, fmt.Errorf("local cache: %w: read deadline: %w", ErrSnapshotCorrupted, err)
+		}
+		var tags []string
+		if version >= 2 {
+			if tags, err = br.readTags(); err != nil {
+				return nil, fmt.Errorf("local cache: %w: read tags: %w", ErrSnapshotCorrupted, err)
+			}
+		}
+		entries = append(entries, snapshotEntry{key: string(key), value: value, deadline: deadline, tags: tags})
+	}
+	want := crc.Sum32()
+	var got uint32
+	if err := binary.Read(br.r, binary.BigEndian, &got); err != nil {
+		return nil, fmt.Errorf("local cache: %w: read checksum: %w", ErrSnapshotCorrupted, err)
+	}
+	if got != want {
+		return nil, fmt.Errorf("local cache: %w: checksum mismatch", ErrSnapshotCorrupted)
+	}
+	return entries, nil
+}
+
+// SnapshotToFile writes a snapshot to path atomically.
+func (c *LocalCache) SnapshotToFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := c.Snapshot(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RestoreFromFile restores a snapshot from path, a missing file is not an error.
+func (c *LocalCache) RestoreFromFile(path string) error {
+	return restoreFromFile(path, c.Restore)
+}
+
+func restoreFromFile(path string, restore func(r io.Reader) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return restore(f)
+}
+
+// snapshot 后台定时写快照
+func (c *LocalCache) snapshot() {
+	if err := c.SnapshotToFile(c.snapshotPath); err != nil {
+		slog.Error("local cache: snapshot error", slog.String("path", c.snapshotPath), slog.String("error", err.Error()))
+	}
+}
+
+// snapshotReader 读取的同时计算校验和
+type snapshotReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (r *snapshotReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	_, _ = r.crc.Write(p[:n])
+	return n, err
+}
+
+func (r *snapshotReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		_, _ = r.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+func (r *snapshotReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > snapshotMaxSize {
+		return nil, fmt.Errorf("length %d too large", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *snapshotReader) readTags() ([]string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	tags := make([]string, 0, min(n, 64))
+	for i := uint64(0); i < n; i++ {
+		tag, err := r.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, string(tag))
+	}
+	return tags, nil
+}
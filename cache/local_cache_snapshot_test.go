@@ -0,0 +1,191 @@
+package _cache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache_SnapshotRestore(t *testing.T) {
+	now := time.Now()
+	src := NewLocalCache(time.Hour, WithClock(clock.NewFake(now)))
+	defer src.Close()
+	ctx := context.Background()
+	require.NoError(t, src.Set(ctx, "forever", []byte("value1"), 0))
+	require.NoError(t, src.Set(ctx, "short", []byte("value2"), time.Second))
+	require.NoError(t, src.Set(ctx, "long", []byte("value3"), time.Minute))
+	require.NoError(t, src.Set(ctx, "empty", []byte{}, 0))
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Snapshot(&buf))
+
+	// 停机 10s 之后恢复，short 已经过期
+	fake := clock.NewFake(now.Add(10 * time.Second))
+	dst := NewLocalCache(time.Hour, WithClock(fake))
+	defer dst.Close()
+	require.NoError(t, dst.Restore(&buf))
+
+	for key, want := range map[string][]byte{
+		"forever": []byte("value1"),
+		"long":    []byte("value3"),
+		"empty":   {},
+	} {
+		val, err := dst.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, want, val)
+	}
+	assert.False(t, dst.Exists(ctx, "short"))
+
+	// 剩余 TTL 为 50s
+	fake.Advance(49 * time.Second)
+	_, err := dst.Get(ctx, "long")
+	require.NoError(t, err)
+	fake.Advance(2 * time.Second)
+	_, err = dst.Get(ctx, "long")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestLocalCache_RestoreCorrupted(t *testing.T) {
+	src := NewLocalCache(time.Hour)
+	defer src.Close()
+	require.NoError(t, src.Set(context.Background(), "key", []byte("value"), 0))
+	var buf bytes.Buffer
+	require.NoError(t, src.Snapshot(&buf))
+	snapshot := buf.Bytes()
+
+	tests := []struct {
+		name     string
+		snapshot func() []byte
+		wantErr  string
+	}{
+		{
+			name:     "empty",
+			snapshot: func() []byte { return nil },
+			wantErr:  "read header",
+		},
+		{
+			name: "bad magic",
+			snapshot: func() []byte {
+				return append([]byte("XXXX"), snapshot[4:]...)
+			},
+			wantErr: "bad magic",
+		},
+		{
+			name: "unsupported version",
+			snapshot: func() []byte {
+				b := bytes.Clone(snapshot)
+				b[4] = 99
+				return b
+			},
+			wantErr: "unsupported version 99",
+		},
+		{
+			name: "checksum mismatch",
+			snapshot: func() []byte {
+				b := bytes.Clone(snapshot)
+				b[len(b)-6] ^= 0xff // 修改 deadline 中的一个字节
+				return b
+			},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name: "truncated",
+			snapshot: func() []byte {
+				return snapshot[:len(snapshot)-2]
+			},
+			wantErr: "read checksum",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := NewLocalCache(time.Hour)
+			defer dst.Close()
+			err := dst.Restore(bytes.NewReader(tt.snapshot()))
+			assert.ErrorIs(t, err, ErrSnapshotCorrupted)
+			assert.ErrorContains(t, err, tt.wantErr)
+			// 校验失败不会写入任何数据
+			assert.False(t, dst.Exists(context.Background(), "key"))
+		})
+	}
+}
+
+func TestLocalCache_WithSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	fake := clock.NewFake(time.Now())
+	c := NewLocalCache(time.Hour, WithClock(fake), WithSnapshot(path, time.Minute))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Hour))
+
+	fake.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, c.Close())
+
+	// 新的实例启动时从快照恢复
+	warm := NewLocalCache(time.Hour, WithClock(fake), WithSnapshot(path, 0))
+	defer warm.Close()
+	val, err := warm.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+
+	// 没有快照文件时正常启动
+	cold := NewLocalCache(time.Hour, WithSnapshot(filepath.Join(t.TempDir(), "not-exist"), 0))
+	defer cold.Close()
+	assert.False(t, cold.Exists(ctx, "key"))
+}
+
+func TestMaxCntCache_Restore(t *testing.T) {
+	src := NewLocalCache(time.Hour)
+	defer src.Close()
+	ctx := context.Background()
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		require.NoError(t, src.Set(ctx, key, []byte(key), 0))
+	}
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	require.NoError(t, src.SnapshotToFile(path))
+
+	// 恢复的数据同样受容量限制
+	c := NewMaxCntCache(3, NewLocalCache(time.Hour))
+	defer c.Close()
+	require.NoError(t, c.RestoreFromFile(path))
+	assert.Equal(t, int32(3), c.cnt)
+	keys := rangeKeys(c.LocalCache)
+	require.Len(t, keys, 3)
+
+	require.NoError(t, c.Delete(ctx, keys[0]))
+	assert.Equal(t, int32(2), c.cnt)
+	require.NoError(t, c.Set(ctx, "key5", []byte("key5"), 0))
+	require.NoError(t, c.Set(ctx, "key6", []byte("key6"), 0))
+	assert.Equal(t, int32(3), c.cnt)
+	assert.Len(t, rangeKeys(c.LocalCache), 3)
+}
+
+func TestMaxCntCache_WithSnapshot(t *testing.T) {
+	src := NewLocalCache(time.Hour)
+	defer src.Close()
+	ctx := context.Background()
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		require.NoError(t, src.Set(ctx, key, []byte(key), 0))
+	}
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	require.NoError(t, src.SnapshotToFile(path))
+
+	// LocalCache 启动时恢复的数据在 NewMaxCntCache 中计数，超出容量的部分被淘汰
+	c := NewMaxCntCache(3, NewLocalCache(time.Hour, WithSnapshot(path, 0)))
+	defer c.Close()
+	assert.Equal(t, int32(3), c.cnt)
+	assert.Len(t, rangeKeys(c.LocalCache), 3)
+
+	require.NoError(t, c.Set(ctx, "key5", []byte("key5"), 0))
+	assert.Equal(t, int32(3), c.cnt)
+	assert.Len(t, rangeKeys(c.LocalCache), 3)
+}
@@ -3,9 +3,12 @@ package _cache
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/LXJ0000/go-combat/clock"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,7 +28,7 @@ func TestLocalCache_Get(t *testing.T) {
 			cache: func() *LocalCache {
 				return NewLocalCache(time.Second * 10)
 			},
-			wantErr: fmt.Errorf("local cache: %w, key: %s", errKeyNotFound, "key1"),
+			wantErr: fmt.Errorf("local cache: %w, key: %s", ErrKeyNotFound, "key1"),
 		},
 		{
 			name: "key found",
@@ -37,19 +40,20 @@ func TestLocalCache_Get(t *testing.T) {
 				return c
 			},
 			wantErr: nil,
-			want:    "value2",
+			want:    []byte("value2"),
 		},
 		{
 			name: "key expired",
 			key:  "key3",
 			cache: func() *LocalCache {
-				c := NewLocalCache(time.Second*10, func(lc *LocalCache) {})
+				fake := clock.NewFake(time.Now())
+				c := NewLocalCache(time.Second*10, WithClock(fake))
 				err := c.Set(context.Background(), "key3", []byte("value3"), time.Second)
 				require.NoError(t, err)
-				time.Sleep(time.Second * 2)
+				fake.Advance(time.Second * 2)
 				return c
 			},
-			wantErr: fmt.Errorf("local cache: %w, key: %s", errKeyNotFound, "key3"),
+			wantErr: fmt.Errorf("local cache: %w, key: %s", ErrKeyNotFound, "key3"),
 		},
 	}
 	for _, tt := range tests {
@@ -65,17 +69,70 @@ func TestLocalCache_Get(t *testing.T) {
 }
 
 func TestLocalCache_Loop(t *testing.T) {
-	var cnt int
+	var cnt atomic.Int32
+	fake := clock.NewFake(time.Now())
 	c := NewLocalCache(time.Second, WithEvict(func(key string, value []byte) {
-		cnt++
-	}))
+		cnt.Add(1)
+	}), WithClock(fake))
+	defer c.Close()
 	err := c.Set(context.Background(), "key1", []byte("value1"), time.Second)
 	require.NoError(t, err)
-	time.Sleep(time.Second * 5)
+	fake.Advance(time.Second * 2)
+	// 清理在后台 goroutine 中进行，等待它消费 tick
+	require.Eventually(t, func() bool {
+		return cnt.Load() == 1
+	}, time.Second, time.Millisecond*10)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	_, ok := c.data["key1"]
 	require.False(t, ok)
-	require.Equal(t, 1, cnt)
+}
+
+func TestLocalCache_Clean(t *testing.T) {
+	base := time.Now()
+	fake := clock.NewFake(base)
+	var evicted []string
+	c := NewLocalCache(time.Hour, WithEvict(func(key string, value []byte) {
+		evicted = append(evicted, key)
+	}), WithClock(fake))
+	defer c.Close()
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key3", []byte("value3"), time.Second*3))
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), time.Second))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), time.Second*2))
+	require.NoError(t, c.Set(ctx, "forever", []byte("forever"), 0))
+	// 覆盖之后按照新的过期时间
+	require.NoError(t, c.Set(ctx, "renew", []byte("renew"), time.Second))
+	require.NoError(t, c.Set(ctx, "renew", []byte("renew"), time.Second*10))
+
+	c.clean(base.Add(time.Millisecond * 2500))
+	assert.Equal(t, []string{"key1", "key2"}, evicted)
+	assert.Len(t, c.data, 3)
+	assert.Len(t, c.expiry, 2)
+
+	c.clean(base.Add(time.Minute))
+	assert.Equal(t, []string{"key1", "key2", "key3", "renew"}, evicted)
+	assert.Len(t, c.data, 1)
+	assert.Empty(t, c.expiry)
+}
+
+func TestLocalCache_CleanMoreThanBatch(t *testing.T) {
+	base := time.Now()
+	c := NewLocalCache(time.Hour, WithClock(clock.NewFake(base)))
+	defer c.Close()
+	ctx := context.Background()
+	for i := 0; i < cleanCount*3; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("key%d", i), []byte("value"), time.Duration(i+1)*time.Millisecond))
+	}
+	// 过期的数量超过单批次的数量，也需要一次清理干净
+	now := base.Add(time.Duration(cleanCount*2)*time.Millisecond + time.Microsecond)
+	c.clean(now)
+	assert.Equal(t, cleanCount, len(c.data))
+	assert.Equal(t, cleanCount, len(c.expiry))
+	for _, it := range c.expiry {
+		assert.True(t, it.deadline.After(now))
+	}
 
+	require.NoError(t, c.Delete(ctx, fmt.Sprintf("key%d", cleanCount*3-1)))
+	assert.Equal(t, cleanCount-1, len(c.expiry))
 }
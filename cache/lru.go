@@ -1,80 +1,306 @@
 package _cache
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
-type Node struct {
-	key   string
-	value *item
-	prev  *Node
-	next  *Node
+	"github.com/LXJ0000/go-combat/clock"
+)
+
+var _ Cache = &LRU{}
+
+type LRUOption func(*lruOptions)
+
+type lruOptions struct {
+	clock clock.Clock
+}
+
+// WithLRUClock 指定判断过期使用的时钟，测试中可以传入 clock.FakeClock
+func WithLRUClock(clock clock.Clock) LRUOption {
+	return func(o *lruOptions) {
+		o.clock = clock
+	}
 }
 
+// Node LRU 链表中的节点
+type Node = lruNode[string, []byte]
+
+// LRU 基于 GenericLRU 实现的 Cache，容量按照 key 的数量计算
 type LRU struct {
-	mp       map[string]*Node
-	head     *Node
-	tail     *Node
+	lru *GenericLRU[string, []byte]
+}
+
+func NewLRU(capacity int, opts ...LRUOption) *LRU {
+	return &LRU{
+		lru: NewGenericLRU[string, []byte](capacity, opts...),
+	}
+}
+
+// Get returns the value for the given key.
+func (c *LRU) Get(ctx context.Context, key string) ([]byte, error) {
+	value, ok := c.lru.Get(key)
+	if !ok {
+		return nil, fmt.Errorf("lru: %w, key: %s", ErrKeyNotFound, key)
+	}
+	return value, nil
+}
+
+// Set sets the value for the given key with an optional expiration time.
+// if expiration is zero, the value will not expire.
+func (c *LRU) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	c.lru.Set(key, value, expiration)
+	return nil
+}
+
+// Put sets the item for the given key, keeping the item's deadline.
+func (c *LRU) Put(key string, value *item) {
+	c.lru.set(key, value.value, value.deadline)
+}
+
+// Delete deletes the value for the given key.
+func (c *LRU) Delete(ctx context.Context, key string) error {
+	c.lru.Delete(key)
+	return nil
+}
+
+// Exists checks if the given key exists in the cache.
+func (c *LRU) Exists(ctx context.Context, key string) bool {
+	return c.lru.Exists(key)
+}
+
+// LoadAndDelete returns the value for the given key and deletes it from the cache.
+func (c *LRU) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	value, ok := c.lru.LoadAndDelete(key)
+	if !ok {
+		return nil, fmt.Errorf("lru: %w, key: %s", ErrKeyNotFound, key)
+	}
+	return value, nil
+}
+
+// OnEvicted sets the callback function which is called when a key is deleted, expired or evicted.
+func (c *LRU) OnEvicted(fn func(key string, value []byte)) {
+	c.lru.OnEvicted(fn)
+}
+
+// Len returns the number of keys in the cache, including expired keys not yet removed.
+func (c *LRU) Len() int {
+	return c.lru.Len()
+}
+
+// Stats returns the hit/miss/eviction counters.
+func (c *LRU) Stats() LRUStats {
+	return c.lru.Stats()
+}
+
+// LRUStats 命中、未命中以及淘汰（容量淘汰与过期）的次数
+type LRUStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// GenericLRU 支持过期时间的 LRU，所有操作均为 O(1)
+// capacity <= 0 时不限制容量
+type GenericLRU[K comparable, V any] struct {
+	mp       map[K]*lruNode[K, V]
+	head     *lruNode[K, V]
+	tail     *lruNode[K, V]
 	capacity int
-	mu       sync.RWMutex // TODO Optimize the performance of using the locks
+	mu       sync.RWMutex
+	evict    func(key K, value V)
+	clock    clock.Clock
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
 }
 
-func NewLRU(capacity int) *LRU {
-	head := &Node{}
-	tail := &Node{}
+type lruNode[K comparable, V any] struct {
+	key      K
+	value    V
+	deadline time.Time
+	prev     *lruNode[K, V]
+	next     *lruNode[K, V]
+}
+
+func (n *lruNode[K, V]) expired(now time.Time) bool {
+	return !n.deadline.IsZero() && now.After(n.deadline)
+}
+
+func NewGenericLRU[K comparable, V any](capacity int, opts ...LRUOption) *GenericLRU[K, V] {
+	o := lruOptions{clock: clock.New()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	head := &lruNode[K, V]{}
+	tail := &lruNode[K, V]{}
 	head.next = tail
 	tail.prev = head
-	return &LRU{
-		mp:       make(map[string]*Node),
+	return &GenericLRU[K, V]{
+		mp:       make(map[K]*lruNode[K, V]),
 		head:     head,
 		tail:     tail,
 		capacity: capacity,
+		clock:    o.clock,
 	}
 }
 
-func (lru *LRU) Get(key string) *item {
+// Get 命中后将 key 移动到队头
+// key 不存在或者已经在队头时只需要读锁，否则需要写锁
+func (lru *GenericLRU[K, V]) Get(key K) (V, bool) {
+	var zero V
+	lru.mu.RLock()
+	node, ok := lru.mp[key]
+	if !ok {
+		lru.mu.RUnlock()
+		lru.misses.Add(1)
+		return zero, false
+	}
+	if lru.head.next == node && !node.expired(lru.clock.Now()) {
+		value := node.value
+		lru.mu.RUnlock()
+		lru.hits.Add(1)
+		return value, true
+	}
+	lru.mu.RUnlock()
+
 	lru.mu.Lock()
 	defer lru.mu.Unlock()
-	node, ok := lru.mp[key]
+	node, ok = lru.mp[key] // double check
 	if !ok {
-		return nil
+		lru.misses.Add(1)
+		return zero, false
+	}
+	if node.expired(lru.clock.Now()) {
+		lru.expire(node)
+		lru.misses.Add(1)
+		return zero, false
 	}
 	lru.moveToHead(node)
-	return node.value
+	lru.hits.Add(1)
+	return node.value, true
+}
+
+// Set 写入或者覆盖 key，expiration 为 0 表示永不过期
+func (lru *GenericLRU[K, V]) Set(key K, value V, expiration time.Duration) {
+	var deadline time.Time
+	if expiration != 0 {
+		deadline = lru.clock.Now().Add(expiration)
+	}
+	lru.set(key, value, deadline)
+}
+
+// Delete 删除 key，返回 key 是否存在
+func (lru *GenericLRU[K, V]) Delete(key K) bool {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+	node, ok := lru.mp[key]
+	if !ok {
+		return false
+	}
+	lru.remove(node)
+	return true
 }
 
-func (lru *LRU) Put(key string, value *item) {
+// Exists 判断 key 是否存在且未过期，不影响 LRU 顺序
+func (lru *GenericLRU[K, V]) Exists(key K) bool {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+	node, ok := lru.mp[key]
+	return ok && !node.expired(lru.clock.Now())
+}
+
+// LoadAndDelete 返回并删除 key，已过期的 key 视为不存在
+func (lru *GenericLRU[K, V]) LoadAndDelete(key K) (V, bool) {
+	var zero V
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+	node, ok := lru.mp[key]
+	if !ok {
+		return zero, false
+	}
+	if node.expired(lru.clock.Now()) {
+		lru.expire(node)
+		return zero, false
+	}
+	lru.remove(node)
+	return node.value, true
+}
+
+// OnEvicted 设置删除、过期以及容量淘汰时的回调，回调在持有锁的情况下执行，不能再调用 lru 的方法
+func (lru *GenericLRU[K, V]) OnEvicted(fn func(key K, value V)) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+	lru.evict = fn
+}
+
+func (lru *GenericLRU[K, V]) Len() int {
+	lru.mu.RLock()
+	defer lru.mu.RUnlock()
+	return len(lru.mp)
+}
+
+func (lru *GenericLRU[K, V]) Stats() LRUStats {
+	return LRUStats{
+		Hits:      lru.hits.Load(),
+		Misses:    lru.misses.Load(),
+		Evictions: lru.evictions.Load(),
+	}
+}
+
+// set 写入或者覆盖 key，deadline 为零值表示永不过期
+func (lru *GenericLRU[K, V]) set(key K, value V, deadline time.Time) {
 	lru.mu.Lock()
 	defer lru.mu.Unlock()
 	node, ok := lru.mp[key]
 	if ok {
 		node.value = value
+		node.deadline = deadline
 		lru.moveToHead(node)
 		return
 	}
-	node = &Node{
-		key:   key,
-		value: value,
+	node = &lruNode[K, V]{
+		key:      key,
+		value:    value,
+		deadline: deadline,
 	}
 	lru.mp[key] = node
 	lru.addNode(node)
-	if len(lru.mp) > lru.capacity {
-		delete(lru.mp, lru.tail.prev.key)
-		lru.removeNode(lru.tail.prev)
+	if lru.capacity > 0 && len(lru.mp) > lru.capacity {
+		lru.expire(lru.tail.prev)
+	}
+}
+
+// expire 因为过期或者容量不足而删除节点
+func (lru *GenericLRU[K, V]) expire(node *lruNode[K, V]) {
+	lru.evictions.Add(1)
+	lru.remove(node)
+}
+
+func (lru *GenericLRU[K, V]) remove(node *lruNode[K, V]) {
+	delete(lru.mp, node.key)
+	lru.removeNode(node)
+	if lru.evict != nil {
+		lru.evict(node.key, node.value)
 	}
 }
 
-func (lru *LRU) moveToHead(node *Node) {
+func (lru *GenericLRU[K, V]) moveToHead(node *lruNode[K, V]) {
 	lru.removeNode(node)
 	lru.addNode(node)
 }
 
-func (lru *LRU) addNode(node *Node) {
+func (lru *GenericLRU[K, V]) addNode(node *lruNode[K, V]) {
 	node.next = lru.head.next
 	lru.head.next.prev = node
 	lru.head.next = node
 	node.prev = lru.head
 }
 
-func (lru *LRU) removeNode(node *Node) {
+func (lru *GenericLRU[K, V]) removeNode(node *lruNode[K, V]) {
 	node.prev.next = node.next
 	node.next.prev = node.prev
 }
@@ -0,0 +1,177 @@
+package _cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_Get(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	tests := []struct {
+		name      string
+		key       string
+		cache     func() *LRU
+		want      []byte
+		wantErr   error
+		wantStats LRUStats
+	}{
+		{
+			name:      "key not found",
+			key:       "key1",
+			cache:     func() *LRU { return NewLRU(2) },
+			wantErr:   ErrKeyNotFound,
+			wantStats: LRUStats{Misses: 1},
+		},
+		{
+			name: "key found",
+			key:  "key1",
+			cache: func() *LRU {
+				c := NewLRU(2)
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				return c
+			},
+			want:      []byte("value1"),
+			wantStats: LRUStats{Hits: 1},
+		},
+		{
+			name: "key expired",
+			key:  "key1",
+			cache: func() *LRU {
+				c := NewLRU(2, WithLRUClock(fake))
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), time.Second))
+				fake.Advance(2 * time.Second)
+				return c
+			},
+			wantErr:   ErrKeyNotFound,
+			wantStats: LRUStats{Misses: 1, Evictions: 1},
+		},
+		{
+			name: "key evicted",
+			key:  "key1",
+			cache: func() *LRU {
+				c := NewLRU(2)
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+				require.NoError(t, c.Set(context.Background(), "key3", []byte("value3"), 0))
+				return c
+			},
+			wantErr:   ErrKeyNotFound,
+			wantStats: LRUStats{Misses: 1, Evictions: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.cache()
+			val, err := c.Get(context.Background(), tt.key)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, val)
+			assert.Equal(t, tt.wantStats, c.Stats())
+		})
+	}
+}
+
+func TestLRU_Evict(t *testing.T) {
+	c := NewLRU(2)
+	var evicted []string
+	c.OnEvicted(func(key string, value []byte) {
+		evicted = append(evicted, key)
+	})
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+	_, err := c.Get(ctx, "key1")
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "key3", []byte("value3"), 0))
+	assert.Equal(t, []string{"key2"}, evicted)
+	assert.False(t, c.Exists(ctx, "key2"))
+
+	require.NoError(t, c.Delete(ctx, "key1"))
+	assert.Equal(t, []string{"key2", "key1"}, evicted)
+
+	val, err := c.LoadAndDelete(ctx, "key3")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value3"), val)
+	assert.Equal(t, 0, c.Len())
+	_, err = c.LoadAndDelete(ctx, "key3")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestLRU_Put(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	c := NewLRU(2, WithLRUClock(fake))
+	ctx := context.Background()
+	c.Put("key1", &item{value: []byte("value1"), deadline: fake.Now().Add(time.Second)})
+	val, err := c.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value1"), val)
+
+	// 使用 item 自带的过期时间
+	fake.Advance(2 * time.Second)
+	_, err = c.Get(ctx, "key1")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestGenericLRU(t *testing.T) {
+	type user struct {
+		name string
+	}
+	lru := NewGenericLRU[int, user](1)
+	lru.Set(1, user{name: "tom"}, 0)
+	u, ok := lru.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, "tom", u.name)
+
+	lru.Set(2, user{name: "jerry"}, 0)
+	_, ok = lru.Get(1)
+	assert.False(t, ok)
+	assert.True(t, lru.Exists(2))
+	assert.Equal(t, LRUStats{Hits: 1, Misses: 1, Evictions: 1}, lru.Stats())
+}
+
+func TestGenericLRU_Concurrent(t *testing.T) {
+	lru := NewGenericLRU[int, int](64)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := (i*1000 + j) % 128
+				lru.Set(key, j, time.Minute)
+				lru.Get(key)
+				if j%10 == 0 {
+					lru.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, lru.Len(), 64)
+}
+
+func TestGenericLRU_GetContended(t *testing.T) {
+	lru := NewGenericLRU[int, int](2)
+	lru.Set(1, 1, 0)
+	lru.Set(2, 2, 0)
+
+	// 其他读者持有读锁时，Get 也要等待并把 key 移动到队头
+	lru.mu.RLock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lru.Get(1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	lru.mu.RUnlock()
+	<-done
+
+	lru.Set(3, 3, 0)
+	assert.True(t, lru.Exists(1))
+	assert.False(t, lru.Exists(2))
+}
@@ -0,0 +1,5 @@
+-- // ARGV 为需要置 1 的位，一次调用完成，避免多次往返
+for _, offset in ipairs(ARGV) do
+    redis.call('setbit', KEYS[1], offset, 1)
+end
+return 1
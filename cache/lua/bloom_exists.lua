@@ -0,0 +1,7 @@
+-- // 所有位都为 1 才可能存在，任意一位为 0 则一定不存在
+for _, offset in ipairs(ARGV) do
+    if redis.call('getbit', KEYS[1], offset) == 0 then
+        return 0
+    end
+end
+return 1
@@ -0,0 +1,18 @@
+-- 放弃等待公平锁：KEYS 与 fair_unlock.lua 相同，ARGV[1] 等待者 ARGV[2] 锁的过期时间（毫秒）
+-- 离开队列，释放自己可能已经持有的锁，锁空闲时唤醒队首的等待者
+redis.call('lrem', KEYS[2], 0, ARGV[1])
+redis.call('zrem', KEYS[3], ARGV[1])
+redis.call('del', KEYS[4] .. ARGV[1])
+local holder = redis.call('get', KEYS[1])
+if holder == ARGV[1] then
+    redis.call('del', KEYS[1])
+elseif holder ~= false then
+    return 0
+end
+local head = redis.call('lindex', KEYS[2], 0)
+if head ~= false then
+    local wake = KEYS[4] .. head
+    redis.call('rpush', wake, 1)
+    redis.call('pexpire', wake, ARGV[2])
+end
+return 1
@@ -0,0 +1,42 @@
+-- 公平锁：KEYS[1] 锁 KEYS[2] 等待队列（list，按照到达顺序） KEYS[3] 等待者的超时时间（zset，毫秒时间戳）
+-- ARGV[1] 加锁的人 ARGV[2] 锁的过期时间（毫秒） ARGV[3] 排队的超时时间（毫秒）
+-- 锁空闲且自己位于队首（或者队列为空）时加锁成功返回 1，否则排队（已经在队列中则刷新超时时间）返回 0
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+-- 清理队首超时的等待者
+while true do
+    local head = redis.call('lindex', KEYS[2], 0)
+    if head == false then
+        break
+    end
+    local deadline = redis.call('zscore', KEYS[3], head)
+    if deadline ~= false and tonumber(deadline) >= now then
+        break
+    end
+    redis.call('lpop', KEYS[2])
+    redis.call('zrem', KEYS[3], head)
+end
+
+local holder = redis.call('get', KEYS[1])
+if holder == ARGV[1] then
+    redis.call('pexpire', KEYS[1], ARGV[2])
+    return 1
+end
+local head = redis.call('lindex', KEYS[2], 0)
+if holder == false and (head == false or head == ARGV[1]) then
+    if head == ARGV[1] then
+        redis.call('lpop', KEYS[2])
+        redis.call('zrem', KEYS[3], ARGV[1])
+    end
+    redis.call('set', KEYS[1], ARGV[1], 'PX', ARGV[2])
+    return 1
+end
+
+if redis.call('zscore', KEYS[3], ARGV[1]) == false then
+    redis.call('rpush', KEYS[2], ARGV[1])
+end
+redis.call('zadd', KEYS[3], now + tonumber(ARGV[3]), ARGV[1])
+-- 每个等待者都会在超时之前刷新，队列的过期时间不小于任何一个等待者
+redis.call('pexpire', KEYS[2], ARGV[3])
+redis.call('pexpire', KEYS[3], ARGV[3])
+return 0
@@ -0,0 +1,27 @@
+-- 公平锁解锁：KEYS[1] 锁 KEYS[2] 等待队列 KEYS[3] 等待者的超时时间 KEYS[4] 唤醒 list 的前缀
+-- ARGV[1] 加锁的人 ARGV[2] 锁的过期时间（毫秒）
+-- 解锁成功返回 1 并唤醒队首未超时的等待者，锁不属于自己时返回 0
+if redis.call('get', KEYS[1]) ~= ARGV[1] then
+    return 0
+end
+redis.call('del', KEYS[1])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+while true do
+    local head = redis.call('lindex', KEYS[2], 0)
+    if head == false then
+        break
+    end
+    local deadline = redis.call('zscore', KEYS[3], head)
+    if deadline ~= false and tonumber(deadline) >= now then
+        -- 唤醒 list 与锁位于同一个 slot
+        local wake = KEYS[4] .. head
+        redis.call('rpush', wake, 1)
+        redis.call('pexpire', wake, ARGV[2])
+        break
+    end
+    redis.call('lpop', KEYS[2])
+    redis.call('zrem', KEYS[3], head)
+end
+return 1
@@ -0,0 +1,12 @@
+-- 可重入锁：KEYS[1] 为 hash，field 为持有者，value 为重入次数
+-- ARGV[1] 持有者 ARGV[2] 过期时间（毫秒）
+-- 返回加锁之后的重入次数，锁被他人持有时返回 0
+-- 只延长过期时间，嵌套加锁使用更短的过期时间时不会缩短外层的租期
+if redis.call('exists', KEYS[1]) == 0 or redis.call('hexists', KEYS[1], ARGV[1]) == 1 then
+    local cnt = redis.call('hincrby', KEYS[1], ARGV[1], 1)
+    if redis.call('pttl', KEYS[1]) < tonumber(ARGV[2]) then
+        redis.call('pexpire', KEYS[1], ARGV[2])
+    end
+    return cnt
+end
+return 0
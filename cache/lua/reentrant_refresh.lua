@@ -0,0 +1,13 @@
+-- 可重入锁刷新过期时间
+-- ARGV[1] 持有者 ARGV[2] 过期时间（毫秒）
+-- 与加锁相同，只延长过期时间，嵌套的 watchdog 不会缩短外层的租期
+if redis.call('hexists', KEYS[1], ARGV[1]) == 1 then
+    -- 锁属于自己
+    if redis.call('pttl', KEYS[1]) < tonumber(ARGV[2]) then
+        redis.call('pexpire', KEYS[1], ARGV[2])
+    end
+    return 1
+else
+    -- 锁不属于自己
+    return 0
+end
@@ -0,0 +1,15 @@
+-- 可重入锁解锁：重入次数减一，减到 0 时删除锁
+-- ARGV[1] 持有者 ARGV[2] 过期时间（毫秒）
+-- 解锁成功返回 1，锁不属于自己时返回 0
+-- 与加锁相同，只延长过期时间
+if redis.call('hexists', KEYS[1], ARGV[1]) == 0 then
+    return 0
+end
+if redis.call('hincrby', KEYS[1], ARGV[1], -1) > 0 then
+    if redis.call('pttl', KEYS[1]) < tonumber(ARGV[2]) then
+        redis.call('pexpire', KEYS[1], ARGV[2])
+    end
+else
+    redis.call('del', KEYS[1])
+end
+return 1
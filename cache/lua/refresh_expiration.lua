@@ -1,8 +1,8 @@
--- 刷新过期时间
+-- 刷新过期时间，ARGV[2] 为毫秒
 if redis.call('get', KEYS[1]) == ARGV[1] then
     -- 锁属于自己
-    return redis.call('expire', KEYS[1], ARGV[2])
+    return redis.call('pexpire', KEYS[1], ARGV[2])
 else
     -- 锁不属于自己
     return 0
-end
\ No newline at end of file
+end
@@ -0,0 +1,15 @@
+-- 读锁：KEYS[1] 写锁 KEYS[2] 读者 zset（score 为过期时间戳，毫秒） KEYS[3] 等待中的写者
+-- ARGV[1] 读者 ARGV[2] 过期时间（毫秒）
+-- 有写者持有或者等待时返回 0，写者优先，避免写者饥饿
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+if redis.call('exists', KEYS[1]) == 1 or redis.call('exists', KEYS[3]) == 1 then
+    return 0
+end
+redis.call('zremrangebyscore', KEYS[2], '-inf', now)
+redis.call('zadd', KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+-- zset 的过期时间不小于任何一个读者
+if redis.call('pttl', KEYS[2]) < tonumber(ARGV[2]) then
+    redis.call('pexpire', KEYS[2], ARGV[2])
+end
+return 1
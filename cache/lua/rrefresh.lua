@@ -0,0 +1,13 @@
+-- 读锁续约：KEYS[2] 读者 zset，ARGV[1] 读者 ARGV[2] 过期时间（毫秒）
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local deadline = redis.call('zscore', KEYS[2], ARGV[1])
+if deadline == false or tonumber(deadline) < now then
+    -- 读者不存在或者已经过期
+    return 0
+end
+redis.call('zadd', KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+if redis.call('pttl', KEYS[2]) < tonumber(ARGV[2]) then
+    redis.call('pexpire', KEYS[2], ARGV[2])
+end
+return 1
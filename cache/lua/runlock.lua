@@ -0,0 +1,10 @@
+-- 读锁解锁：KEYS[2] 读者 zset，ARGV[1] 读者
+-- 解锁成功返回 1，读者不存在或者已经过期时返回 0
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local deadline = redis.call('zscore', KEYS[2], ARGV[1])
+redis.call('zrem', KEYS[2], ARGV[1])
+if deadline == false or tonumber(deadline) < now then
+    return 0
+end
+return 1
@@ -0,0 +1,25 @@
+-- 写锁：KEYS[1] 写锁 KEYS[2] 读者 zset KEYS[3] 等待中的写者
+-- ARGV[1] 写者 ARGV[2] 过期时间（毫秒）
+-- 没有写者与未过期的读者时加锁成功返回 1
+-- 否则登记为等待中的写者，阻止新的读者加锁，返回 0
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('zremrangebyscore', KEYS[2], '-inf', now)
+local writer = redis.call('get', KEYS[1])
+if writer == ARGV[1] then
+    redis.call('pexpire', KEYS[1], ARGV[2])
+    return 1
+end
+local waiting = redis.call('get', KEYS[3])
+if writer == false and redis.call('zcard', KEYS[2]) == 0 and (waiting == false or waiting == ARGV[1]) then
+    redis.call('set', KEYS[1], ARGV[1], 'PX', ARGV[2])
+    if waiting == ARGV[1] then
+        redis.call('del', KEYS[3])
+    end
+    return 1
+end
+-- 同一时间只有一个写者登记等待
+if waiting == false or waiting == ARGV[1] then
+    redis.call('set', KEYS[3], ARGV[1], 'PX', ARGV[2])
+end
+return 0
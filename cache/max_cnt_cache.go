@@ -2,49 +2,178 @@ package _cache
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
-var (
-	errOverCapacity = errors.New("max cnt cache: over capacity")
-)
+type MaxCntCacheOption func(*MaxCntCache)
+
+// WithEvictionPolicy 指定缓存满了之后的淘汰策略，默认为 LRU
+func WithEvictionPolicy(policy EvictionPolicy) MaxCntCacheOption {
+	return func(c *MaxCntCache) {
+		c.policy = policy
+	}
+}
 
 type MaxCntCache struct {
 	*LocalCache
 	cnt    int32
 	maxCnt int32
+
+	policy   EvictionPolicy
+	policyMu sync.Mutex // policy 不是并发安全的，Get 时不持有 LocalCache 的写锁，需要单独加锁
+	onEvict  func(key string, value []byte)
 }
 
-func NewMaxCntCache(maxCnt int32, c *LocalCache) *MaxCntCache {
+func NewMaxCntCache(maxCnt int32, c *LocalCache, opts ...MaxCntCacheOption) *MaxCntCache {
 	cache := &MaxCntCache{
 		LocalCache: c,
 		cnt:        0,
 		maxCnt:     maxCnt,
+		policy:     NewLRUPolicy(),
 	}
-	originEvict := cache.evict
+	for _, opt := range opts {
+		opt(cache)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache.onEvict = cache.evict
+	// 无论是主动删除、过期还是被淘汰，都会走到这里
 	cache.evict = func(key string, value []byte) {
 		atomic.AddInt32(&cache.cnt, -1)
-		if originEvict != nil {
-			originEvict(key, value)
+		cache.policyMu.Lock()
+		cache.policy.Remove(key)
+		cache.policyMu.Unlock()
+		if cache.onEvict != nil {
+			cache.onEvict(key, value)
 		}
 	}
+	// 统计 c 中已有的数据，例如 WithSnapshot 启动时恢复的数据，超出容量的部分按照策略淘汰
+	for _, key := range slices.Sorted(maps.Keys(c.data)) {
+		cache.cnt++
+		cache.policy.Add(key)
+	}
+	for cache.cnt > cache.maxCnt {
+		victim, ok := cache.policy.Victim()
+		if !ok {
+			break
+		}
+		c.delete(victim)
+	}
 	return cache
 }
 
+// OnEvicted sets the callback function which is called when a key is deleted, expired or evicted.
+// 不能直接使用 LocalCache.OnEvicted，否则会覆盖维护 cnt 的回调
+func (c *MaxCntCache) OnEvicted(fn func(key string, value []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = fn
+}
+
+// Get returns the value for the given key and records the access for the eviction policy.
+func (c *MaxCntCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.LocalCache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.policyMu.Lock()
+	c.policy.Access(key)
+	c.policyMu.Unlock()
+	return value, nil
+}
+
+// Set sets the value for the given key, evicting a key chosen by the policy when the cache is full.
 func (c *MaxCntCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return c.SetWithTags(ctx, key, value, expiration)
+}
+
+// SetWithTags sets the value with tags like LocalCache.SetWithTags, evicting a key chosen by the policy when the cache is full.
+func (c *MaxCntCache) SetWithTags(ctx context.Context, key string, value []byte, expiration time.Duration, tags ...string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("max cnt cache: %w", ErrCacheClosed)
+	}
 	_, ok := c.data[key]
-	if !ok {
-		if c.cnt+1 > c.maxCnt {
-			// 设计淘汰策略
-			return errOverCapacity
+	if ok {
+		c.policyMu.Lock()
+		c.policy.Access(key)
+		c.policyMu.Unlock()
+		return c.setWithTags(key, value, expiration, slices.Clone(tags))
+	}
+	for atomic.LoadInt32(&c.cnt)+1 > c.maxCnt {
+		c.policyMu.Lock()
+		victim, ok := c.policy.Victim()
+		c.policyMu.Unlock()
+		if !ok {
+			return fmt.Errorf("max cnt cache: %w, key: %s", ErrOverCapacity, key)
 		}
-		c.cnt++
+		if _, exist := c.data[victim]; !exist {
+			// 策略与缓存不一致，丢弃这个 key 避免死循环
+			c.policyMu.Lock()
+			c.policy.Remove(victim)
+			c.policyMu.Unlock()
+			continue
+		}
+		c.delete(victim) // 通过 evict 回调维护 cnt 与 policy
+	}
+	atomic.AddInt32(&c.cnt, 1)
+	c.policyMu.Lock()
+	c.policy.Add(key)
+	c.policyMu.Unlock()
+	return c.setWithTags(key, value, expiration, slices.Clone(tags))
+}
+
+// MGet returns the values of the keys that exist and records the accesses for the eviction policy.
+func (c *MaxCntCache) MGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values, err := c.LocalCache.MGet(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	c.policyMu.Lock()
+	for key := range values {
+		c.policy.Access(key)
 	}
-	return c.set(key, value, expiration)
+	c.policyMu.Unlock()
+	return values, nil
 }
 
-// TODO lru
\ No newline at end of file
+// MSet sets the entries one by one, so that every new key goes through the eviction policy.
+func (c *MaxCntCache) MSet(ctx context.Context, entries map[string][]byte, expiration time.Duration) error {
+	for key, value := range entries {
+		if err := c.Set(ctx, key, value, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore restores a snapshot like LocalCache.Restore, setting the entries one by one so that they are counted and evicted by the policy.
+func (c *MaxCntCache) Restore(r io.Reader) error {
+	entries, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	now := c.clock.Now()
+	for _, e := range entries {
+		expiration, ok := e.expiration(now)
+		if !ok {
+			continue
+		}
+		if err := c.SetWithTags(context.Background(), e.key, e.value, expiration, e.tags...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreFromFile restores a snapshot from path like Restore, a missing file is not an error.
+func (c *MaxCntCache) RestoreFromFile(path string) error {
+	return restoreFromFile(path, c.Restore)
+}
@@ -0,0 +1,152 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxCntCache_Set(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxCnt      int32
+		opts        []MaxCntCacheOption
+		before      func(c *MaxCntCache)
+		key         string
+		wantErr     error
+		wantEvicted []string
+		wantKeys    []string
+	}{
+		{
+			name:     "not full",
+			maxCnt:   2,
+			key:      "key1",
+			wantKeys: []string{"key1"},
+		},
+		{
+			name:   "update existing key",
+			maxCnt: 1,
+			before: func(c *MaxCntCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+			},
+			key:      "key1",
+			wantKeys: []string{"key1"},
+		},
+		{
+			name:   "evict lru by default",
+			maxCnt: 2,
+			before: func(c *MaxCntCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+				_, err := c.Get(context.Background(), "key1")
+				require.NoError(t, err)
+			},
+			key:         "key3",
+			wantEvicted: []string{"key2"},
+			wantKeys:    []string{"key1", "key3"},
+		},
+		{
+			name:   "evict fifo",
+			maxCnt: 2,
+			opts:   []MaxCntCacheOption{WithEvictionPolicy(NewFIFOPolicy())},
+			before: func(c *MaxCntCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+				_, err := c.Get(context.Background(), "key1")
+				require.NoError(t, err)
+			},
+			key:         "key3",
+			wantEvicted: []string{"key1"},
+			wantKeys:    []string{"key2", "key3"},
+		},
+		{
+			name:   "evict lfu",
+			maxCnt: 2,
+			opts:   []MaxCntCacheOption{WithEvictionPolicy(NewLFUPolicy())},
+			before: func(c *MaxCntCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+				_, err := c.Get(context.Background(), "key2")
+				require.NoError(t, err)
+			},
+			key:         "key3",
+			wantEvicted: []string{"key1"},
+			wantKeys:    []string{"key2", "key3"},
+		},
+		{
+			name:   "evict arc",
+			maxCnt: 2,
+			opts:   []MaxCntCacheOption{WithEvictionPolicy(NewARCPolicy(2))},
+			before: func(c *MaxCntCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+				_, err := c.Get(context.Background(), "key1")
+				require.NoError(t, err)
+			},
+			key:         "key3",
+			wantEvicted: []string{"key2"},
+			wantKeys:    []string{"key1", "key3"},
+		},
+		{
+			name:    "zero capacity",
+			maxCnt:  0,
+			key:     "key1",
+			wantErr: ErrOverCapacity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var evicted []string
+			local := NewLocalCache(time.Minute, WithEvict(func(key string, value []byte) {
+				evicted = append(evicted, key)
+			}))
+			defer local.Close()
+			c := NewMaxCntCache(tt.maxCnt, local, tt.opts...)
+			if tt.before != nil {
+				tt.before(c)
+			}
+			err := c.Set(context.Background(), tt.key, []byte("value"), 0)
+			assert.True(t, errors.Is(err, tt.wantErr))
+			assert.Equal(t, tt.wantEvicted, evicted)
+			assert.Equal(t, int32(len(tt.wantKeys)), c.cnt)
+			for _, key := range tt.wantKeys {
+				assert.True(t, c.Exists(context.Background(), key), key)
+			}
+		})
+	}
+}
+
+func TestMaxCntCache_Delete(t *testing.T) {
+	c := NewMaxCntCache(2, NewLocalCache(time.Minute))
+	defer c.Close()
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+	require.NoError(t, c.Delete(ctx, "key1"))
+	assert.Equal(t, int32(1), c.cnt)
+
+	// 删除之后有空位，不需要淘汰 key2
+	require.NoError(t, c.Set(ctx, "key3", []byte("value3"), 0))
+	assert.True(t, c.Exists(ctx, "key2"))
+	assert.True(t, c.Exists(ctx, "key3"))
+	assert.Equal(t, int32(2), c.cnt)
+}
+
+func TestMaxCntCache_OnEvicted(t *testing.T) {
+	c := NewMaxCntCache(1, NewLocalCache(time.Minute))
+	defer c.Close()
+	var evicted []string
+	// 设置回调不能影响 cnt 的维护
+	c.OnEvicted(func(key string, value []byte) {
+		evicted = append(evicted, key)
+	})
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+	assert.Equal(t, []string{"key1"}, evicted)
+	assert.Equal(t, int32(1), c.cnt)
+}
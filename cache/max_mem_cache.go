@@ -1,10 +1,181 @@
 package _cache
 
-import "sync"
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
 
+// ValueTooLargeError 单个键值对的大小超过了整个缓存的容量，无论淘汰多少数据都放不下
+type ValueTooLargeError struct {
+	Key  string
+	Size int64
+	Max  int64
+}
+
+func (e *ValueTooLargeError) Error() string {
+	return fmt.Sprintf("max mem cache: %s, key: %s, size: %d, max: %d", ErrValueTooLarge, e.Key, e.Size, e.Max)
+}
+
+func (e *ValueTooLargeError) Unwrap() error {
+	return ErrValueTooLarge
+}
+
+// MaxMemCache 控制整体内存大小，大小按照 len(key)+len(value) 计算
+// 超过 max 之后按照 LRU 的顺序淘汰，直到重新回到 max 以内
 type MaxMemCache struct {
 	Cache
 	max int64
 	use int64
-	mu  sync.Mutex
+	mu  sync.Mutex // 串行化写操作，保证淘汰与写入是一个整体
+
+	// accMu 保护 use/keys/index
+	// 底层缓存的 evict 回调可能在持有 mu 的时候被调用（例如 Set 中淘汰数据），所以需要单独一把锁
+	accMu sync.Mutex
+	keys  *list.List // front 为最近访问
+	index map[string]*list.Element
+	evict func(key string, value []byte)
+}
+
+type memEntry struct {
+	key  string
+	size int64
+}
+
+// NewMaxMemCache 在 cache 的 OnEvicted 回调中扣减已使用的内存，底层缓存自己过期、淘汰的 key 也会被扣减
+// 直接覆盖 cache 的回调会导致内存统计失效，监听淘汰事件请使用 MaxMemCache.OnEvicted
+func NewMaxMemCache(max int64, cache Cache) *MaxMemCache {
+	c := &MaxMemCache{
+		Cache: cache,
+		max:   max,
+		keys:  list.New(),
+		index: make(map[string]*list.Element),
+	}
+	// 过期、删除、淘汰都会走到这里，统一在这里扣减内存
+	cache.OnEvicted(c.onEvicted)
+	return c
+}
+
+// Get returns the value for the given key and marks it as recently used.
+func (c *MaxMemCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.accMu.Lock()
+	if e, ok := c.index[key]; ok {
+		c.keys.MoveToFront(e)
+	}
+	c.accMu.Unlock()
+	return value, nil
+}
+
+// Set sets the value for the given key, evicting the least recently used keys until it fits into max.
+func (c *MaxMemCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	size := int64(len(key) + len(value))
+	if size > c.max {
+		return &ValueTooLargeError{Key: key, Size: size, Max: c.max}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.accMu.Lock()
+	var old int64 // 覆盖写时旧值占用的大小
+	if e, ok := c.index[key]; ok {
+		old = e.Value.(*memEntry).size
+	}
+	for c.use-old+size > c.max {
+		victim, ok := c.oldest(key)
+		if !ok {
+			break
+		}
+		c.accMu.Unlock()
+		_ = c.Cache.Delete(ctx, victim)
+		c.accMu.Lock()
+		c.remove(victim) // 底层缓存没有触发回调的情况下兜底
+	}
+	c.accMu.Unlock()
+
+	if err := c.Cache.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	c.accMu.Lock()
+	c.remove(key)
+	c.index[key] = c.keys.PushFront(&memEntry{key: key, size: size})
+	c.use += size
+	c.accMu.Unlock()
+	return nil
+}
+
+// Delete deletes the value for the given key.
+func (c *MaxMemCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.Cache.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.accMu.Lock()
+	c.remove(key)
+	c.accMu.Unlock()
+	return nil
+}
+
+// LoadAndDelete returns the value for the given key and deletes it from the cache.
+func (c *MaxMemCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, err := c.Cache.LoadAndDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.accMu.Lock()
+	c.remove(key)
+	c.accMu.Unlock()
+	return value, nil
+}
+
+// OnEvicted sets the callback function which is called when a key is deleted, expired or evicted.
+func (c *MaxMemCache) OnEvicted(fn func(key string, value []byte)) {
+	c.accMu.Lock()
+	defer c.accMu.Unlock()
+	c.evict = fn
+}
+
+// Used returns the number of bytes currently used by keys and values.
+func (c *MaxMemCache) Used() int64 {
+	c.accMu.Lock()
+	defer c.accMu.Unlock()
+	return c.use
+}
+
+func (c *MaxMemCache) onEvicted(key string, value []byte) {
+	c.accMu.Lock()
+	c.remove(key)
+	evict := c.evict
+	c.accMu.Unlock()
+	if evict != nil {
+		evict(key, value)
+	}
+}
+
+// oldest 返回最久未访问的 key，跳过正在写入的 key
+func (c *MaxMemCache) oldest(except string) (string, bool) {
+	for e := c.keys.Back(); e != nil; e = e.Prev() {
+		if key := e.Value.(*memEntry).key; key != except {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func (c *MaxMemCache) remove(key string) {
+	e, ok := c.index[key]
+	if !ok {
+		return
+	}
+	c.use -= e.Value.(*memEntry).size
+	c.keys.Remove(e)
+	delete(c.index, key)
 }
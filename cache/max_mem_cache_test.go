@@ -0,0 +1,126 @@
+package _cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxMemCache_Set(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         int64
+		before      func(c *MaxMemCache)
+		key         string
+		value       []byte
+		wantErr     error
+		wantUsed    int64
+		wantEvicted []string
+		wantKeys    []string
+	}{
+		{
+			name:     "not full",
+			max:      100,
+			key:      "key1",
+			value:    []byte("value1"),
+			wantUsed: 10,
+			wantKeys: []string{"key1"},
+		},
+		{
+			name:    "value too large",
+			max:     10,
+			key:     "key1",
+			value:   []byte("value12"),
+			wantErr: &ValueTooLargeError{Key: "key1", Size: 11, Max: 10},
+		},
+		{
+			name: "overwrite",
+			max:  20,
+			before: func(c *MaxMemCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+			},
+			key:      "key1",
+			value:    []byte("v1"),
+			wantUsed: 16,
+			wantKeys: []string{"key1", "key2"},
+		},
+		{
+			name: "evict lru",
+			max:  25,
+			before: func(c *MaxMemCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+				_, err := c.Get(context.Background(), "key1")
+				require.NoError(t, err)
+			},
+			key:         "key3",
+			value:       []byte("value3"),
+			wantUsed:    20,
+			wantEvicted: []string{"key2"},
+			wantKeys:    []string{"key1", "key3"},
+		},
+		{
+			name: "evict until fits",
+			max:  30,
+			before: func(c *MaxMemCache) {
+				require.NoError(t, c.Set(context.Background(), "key1", []byte("value1"), 0))
+				require.NoError(t, c.Set(context.Background(), "key2", []byte("value2"), 0))
+				require.NoError(t, c.Set(context.Background(), "key3", []byte("value3"), 0))
+			},
+			key:         "key4",
+			value:       []byte("value4-value4"),
+			wantUsed:    27,
+			wantEvicted: []string{"key1", "key2"},
+			wantKeys:    []string{"key3", "key4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewLocalCache(time.Minute)
+			defer local.Close()
+			c := NewMaxMemCache(tt.max, local)
+			var evicted []string
+			if tt.before != nil {
+				tt.before(c)
+			}
+			c.OnEvicted(func(key string, value []byte) {
+				evicted = append(evicted, key)
+			})
+			err := c.Set(context.Background(), tt.key, tt.value, 0)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUsed, c.Used())
+			assert.Equal(t, tt.wantEvicted, evicted)
+			for _, key := range tt.wantKeys {
+				assert.True(t, c.Exists(context.Background(), key), key)
+			}
+		})
+	}
+}
+
+func TestMaxMemCache_Delete(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := NewMaxMemCache(100, local)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+	require.NoError(t, c.Set(ctx, "key3", []byte("value3"), time.Millisecond))
+
+	require.NoError(t, c.Delete(ctx, "key1"))
+	assert.Equal(t, int64(20), c.Used())
+
+	val, err := c.LoadAndDelete(ctx, "key2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value2"), val)
+	assert.Equal(t, int64(10), c.Used())
+
+	// 过期的 key 在访问时被删除，同样需要扣减
+	time.Sleep(time.Millisecond * 10)
+	_, err = c.Get(ctx, "key3")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int64(0), c.Used())
+}
@@ -0,0 +1,249 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+)
+
+var _ Cache = &MetricsCache{}
+
+// DefaultLatencyBuckets 与 Prometheus 默认的 bucket 相同
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+type MetricsCacheOption func(*MetricsCache)
+
+// WithLatencyBuckets 加载耗时直方图的 bucket 上界，默认 DefaultLatencyBuckets
+func WithLatencyBuckets(buckets []time.Duration) MetricsCacheOption {
+	return func(c *MetricsCache) {
+		c.latency = newLatencyHistogram(buckets)
+	}
+}
+
+// WithMetricsClock 指定统计加载耗时使用的时钟，测试中可以传入 clock.FakeClock
+func WithMetricsClock(clock clock.Clock) MetricsCacheOption {
+	return func(c *MetricsCache) {
+		c.clock = clock
+	}
+}
+
+// MetricsCache 统计任意 Cache 的命中、未命中、写入、删除、淘汰次数
+// 加载相关的指标需要通过 InstrumentLoad 包装 LoadFunc
+type MetricsCache struct {
+	Cache
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	sets       atomic.Uint64
+	deletes    atomic.Uint64
+	evictions  atomic.Uint64
+	loads      atomic.Uint64
+	loadErrors atomic.Uint64
+	latency    *latencyHistogram
+	clock      clock.Clock
+
+	mu    sync.RWMutex
+	evict func(key string, value []byte)
+	// deleting 正在通过 Delete、LoadAndDelete 删除的 key，期间的回调不算淘汰
+	deleting map[string]int
+}
+
+// CacheStats MetricsCache 的统计快照
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Sets    uint64
+	Deletes uint64
+	// Evictions 底层缓存过期、淘汰 key 的次数，通过 MetricsCache 删除的 key 只计入 Deletes
+	Evictions   uint64
+	Loads       uint64
+	LoadErrors  uint64
+	LoadLatency LatencyStats
+}
+
+// HitRate returns hits / (hits + misses), 0 when there is no read.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// LatencyStats 加载耗时直方图，Counts[i] 为耗时 <= Buckets[i] 的次数（累计值），与 Prometheus 一致
+type LatencyStats struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// NewMetricsCache 淘汰次数来自 cache 的 OnEvicted 回调（不含通过 MetricsCache 删除的 key），监听淘汰事件请使用 MetricsCache.OnEvicted
+func NewMetricsCache(cache Cache, opts ...MetricsCacheOption) *MetricsCache {
+	c := &MetricsCache{
+		Cache:   cache,
+		latency:  newLatencyHistogram(DefaultLatencyBuckets),
+		clock:    clock.New(),
+		deleting: make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	cache.OnEvicted(c.onEvicted)
+	return c
+}
+
+// Get returns the value for the given key and records a hit or a miss.
+func (c *MetricsCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if err == nil {
+		c.hits.Add(1)
+	} else if errors.Is(err, ErrKeyNotFound) {
+		c.misses.Add(1)
+	}
+	return value, err
+}
+
+// Set sets the value for the given key.
+func (c *MetricsCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	err := c.Cache.Set(ctx, key, value, expiration)
+	if err == nil {
+		c.sets.Add(1)
+	}
+	return err
+}
+
+// Delete deletes the value for the given key.
+func (c *MetricsCache) Delete(ctx context.Context, key string) error {
+	done := c.markDeleting(key)
+	err := c.Cache.Delete(ctx, key)
+	done()
+	if err == nil {
+		c.deletes.Add(1)
+	}
+	return err
+}
+
+// LoadAndDelete returns the value for the given key and deletes it, a found key counts as a hit and a delete.
+func (c *MetricsCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	done := c.markDeleting(key)
+	value, err := c.Cache.LoadAndDelete(ctx, key)
+	done()
+	if err == nil {
+		c.hits.Add(1)
+		c.deletes.Add(1)
+	} else if errors.Is(err, ErrKeyNotFound) {
+		c.misses.Add(1)
+	}
+	return value, err
+}
+
+// OnEvicted sets the callback function which is called when a key is deleted, expired or evicted.
+func (c *MetricsCache) OnEvicted(fn func(key string, value []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict = fn
+}
+
+// InstrumentLoad wraps a LoadFunc to record load count, errors and latency.
+// 例如 NewReadThroughCache(c, c.InstrumentLoad(loadFunc), expiration)
+// 数据源中不存在（ErrKeyNotFound）不算加载失败
+func (c *MetricsCache) InstrumentLoad(load func(ctx context.Context, key string) ([]byte, error)) func(ctx context.Context, key string) ([]byte, error) {
+	return func(ctx context.Context, key string) ([]byte, error) {
+		start := c.clock.Now()
+		value, err := load(ctx, key)
+		c.latency.observe(c.clock.Now().Sub(start))
+		c.loads.Add(1)
+		if err != nil && !errors.Is(err, ErrKeyNotFound) {
+			c.loadErrors.Add(1)
+		}
+		return value, err
+	}
+}
+
+// Stats returns a snapshot of the counters.
+func (c *MetricsCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Sets:        c.sets.Load(),
+		Deletes:     c.deletes.Load(),
+		Evictions:   c.evictions.Load(),
+		Loads:       c.loads.Load(),
+		LoadErrors:  c.loadErrors.Load(),
+		LoadLatency: c.latency.snapshot(),
+	}
+}
+
+// markDeleting 标记 key 正在被主动删除，返回的函数取消标记
+// 删除期间同一个 key 恰好过期的回调也不会计入淘汰，这个误差可以接受
+func (c *MetricsCache) markDeleting(key string) func() {
+	c.mu.Lock()
+	c.deleting[key]++
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.deleting[key]--; c.deleting[key] == 0 {
+			delete(c.deleting, key)
+		}
+	}
+}
+
+func (c *MetricsCache) onEvicted(key string, value []byte) {
+	c.mu.RLock()
+	deleting := c.deleting[key] > 0
+	evict := c.evict
+	c.mu.RUnlock()
+	if !deleting {
+		c.evictions.Add(1)
+	}
+	if evict != nil {
+		evict(key, value)
+	}
+}
+
+// latencyHistogram 无锁直方图，counts 最后一个元素为 +Inf
+type latencyHistogram struct {
+	buckets []time.Duration
+	counts  []atomic.Uint64
+	sum     atomic.Int64
+}
+
+func newLatencyHistogram(buckets []time.Duration) *latencyHistogram {
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	return &latencyHistogram{
+		buckets: buckets,
+		counts:  make([]atomic.Uint64, len(buckets)+1),
+	}
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i := sort.Search(len(h.buckets), func(i int) bool { return d <= h.buckets[i] })
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *latencyHistogram) snapshot() LatencyStats {
+	s := LatencyStats{
+		Buckets: append([]time.Duration(nil), h.buckets...),
+		Counts:  make([]uint64, len(h.buckets)),
+		Sum:     time.Duration(h.sum.Load()),
+	}
+	var cumulative uint64
+	for i := range h.buckets {
+		cumulative += h.counts[i].Load()
+		s.Counts[i] = cumulative
+	}
+	s.Count = cumulative + h.counts[len(h.buckets)].Load()
+	return s
+}
@@ -0,0 +1,124 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsCache(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Minute, WithClock(fake))
+	defer local.Close()
+	c := NewMetricsCache(local)
+	var evicted []string
+	c.OnEvicted(func(key string, value []byte) {
+		evicted = append(evicted, key)
+	})
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+	_, err := c.Get(ctx, "key1")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "not-exist")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, c.Delete(ctx, "key1"))
+	_, err = c.LoadAndDelete(ctx, "key2")
+	require.NoError(t, err)
+	// 过期才算淘汰，主动删除只计入 Deletes
+	require.NoError(t, c.Set(ctx, "key3", []byte("value3"), time.Second))
+	fake.Advance(2 * time.Second)
+	_, err = c.Get(ctx, "key3")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(3), stats.Sets)
+	assert.Equal(t, uint64(2), stats.Deletes)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.InDelta(t, 2.0/4, stats.HitRate(), 0.001)
+	// 回调依旧会收到所有删除的 key
+	assert.Equal(t, []string{"key1", "key2", "key3"}, evicted)
+}
+
+func TestMetricsCache_InstrumentLoad(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	fake := clock.NewFake(time.Now())
+	c := NewMetricsCache(local, WithLatencyBuckets([]time.Duration{100 * time.Millisecond, 10 * time.Millisecond}), WithMetricsClock(fake))
+	loadErr := errors.New("db down")
+	rt := NewReadThroughCache(c, c.InstrumentLoad(func(ctx context.Context, key string) ([]byte, error) {
+		switch key {
+		case "slow":
+			fake.Advance(20 * time.Millisecond)
+			return []byte("value"), nil
+		case "error":
+			return nil, loadErr
+		case "not-exist":
+			return nil, ErrKeyNotFound
+		}
+		return []byte("value"), nil
+	}), time.Minute)
+	ctx := context.Background()
+
+	for _, key := range []string{"fast", "slow", "error", "not-exist", "fast"} {
+		_, _ = rt.Get(ctx, key)
+	}
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(4), stats.Misses)
+	assert.Equal(t, uint64(4), stats.Loads)
+	assert.Equal(t, uint64(1), stats.LoadErrors)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}, stats.LoadLatency.Buckets)
+	assert.Equal(t, []uint64{3, 4}, stats.LoadLatency.Counts)
+	assert.Equal(t, uint64(4), stats.LoadLatency.Count)
+	assert.Equal(t, 20*time.Millisecond, stats.LoadLatency.Sum)
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := NewMetricsCache(local, WithLatencyBuckets([]time.Duration{time.Second}))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), 0))
+	_, _ = c.Get(ctx, "key")
+	_, _ = c.InstrumentLoad(func(ctx context.Context, key string) ([]byte, error) {
+		return nil, nil
+	})(ctx, "key")
+
+	p := NewPrometheusCollector("app")
+	p.Register(`user"s`, c)
+	recorder := httptest.NewRecorder()
+	p.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	body := recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE app_cache_hits_total counter",
+		`app_cache_hits_total{cache="user\"s"} 1`,
+		`app_cache_misses_total{cache="user\"s"} 0`,
+		`app_cache_sets_total{cache="user\"s"} 1`,
+		`app_cache_loads_total{cache="user\"s"} 1`,
+		"# TYPE app_cache_load_duration_seconds histogram",
+		`app_cache_load_duration_seconds_bucket{cache="user\"s",le="1"} 1`,
+		`app_cache_load_duration_seconds_bucket{cache="user\"s",le="+Inf"} 1`,
+		`app_cache_load_duration_seconds_count{cache="user\"s"} 1`,
+	} {
+		assert.Contains(t, strings.Split(body, "\n"), line)
+	}
+
+	p.Unregister(`user"s`)
+	var buf strings.Builder
+	_, err := p.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "user")
+}
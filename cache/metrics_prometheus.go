@@ -0,0 +1,113 @@
+package _cache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var _ http.Handler = &PrometheusCollector{}
+
+// PrometheusCollector 以 Prometheus 文本格式输出多个 MetricsCache 的指标，不依赖 Prometheus 客户端库
+// 每个缓存以 cache="name" 标签区分，可以直接挂载到 /metrics
+type PrometheusCollector struct {
+	namespace string
+	mu        sync.RWMutex
+	caches    map[string]*MetricsCache
+}
+
+// NewPrometheusCollector namespace 不为空时作为指标名的前缀，例如 myapp_cache_hits_total
+func NewPrometheusCollector(namespace string) *PrometheusCollector {
+	return &PrometheusCollector{
+		namespace: namespace,
+		caches:    make(map[string]*MetricsCache),
+	}
+}
+
+// Register adds a cache under the given name, registering the same name again replaces it.
+func (p *PrometheusCollector) Register(name string, c *MetricsCache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.caches[name] = c
+}
+
+// Unregister removes the cache with the given name.
+func (p *PrometheusCollector) Unregister(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.caches, name)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (p *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	p.mu.RLock()
+	names := make([]string, 0, len(p.caches))
+	stats := make(map[string]CacheStats, len(p.caches))
+	for name, c := range p.caches {
+		names = append(names, name)
+		stats[name] = c.Stats()
+	}
+	p.mu.RUnlock()
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	counters := []struct {
+		name  string
+		help  string
+		value func(s CacheStats) uint64
+	}{
+		{name: "hits_total", help: "Number of cache hits.", value: func(s CacheStats) uint64 { return s.Hits }},
+		{name: "misses_total", help: "Number of cache misses.", value: func(s CacheStats) uint64 { return s.Misses }},
+		{name: "sets_total", help: "Number of successful cache sets.", value: func(s CacheStats) uint64 { return s.Sets }},
+		{name: "deletes_total", help: "Number of successful cache deletes.", value: func(s CacheStats) uint64 { return s.Deletes }},
+		{name: "evictions_total", help: "Number of keys expired or evicted by the underlying cache, explicit deletes are not counted.", value: func(s CacheStats) uint64 { return s.Evictions }},
+		{name: "loads_total", help: "Number of loads from the data source.", value: func(s CacheStats) uint64 { return s.Loads }},
+		{name: "load_errors_total", help: "Number of failed loads from the data source.", value: func(s CacheStats) uint64 { return s.LoadErrors }},
+	}
+	for _, counter := range counters {
+		metric := p.metricName(counter.name)
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s counter\n", metric, counter.help, metric)
+		for _, name := range names {
+			fmt.Fprintf(&buf, "%s{cache=%s} %d\n", metric, quoteLabel(name), counter.value(stats[name]))
+		}
+	}
+
+	metric := p.metricName("load_duration_seconds")
+	fmt.Fprintf(&buf, "# HELP %s Latency of loads from the data source.\n# TYPE %s histogram\n", metric, metric)
+	for _, name := range names {
+		label := quoteLabel(name)
+		latency := stats[name].LoadLatency
+		for i, bucket := range latency.Buckets {
+			le := strconv.FormatFloat(bucket.Seconds(), 'g', -1, 64)
+			fmt.Fprintf(&buf, "%s_bucket{cache=%s,le=\"%s\"} %d\n", metric, label, le, latency.Counts[i])
+		}
+		fmt.Fprintf(&buf, "%s_bucket{cache=%s,le=\"+Inf\"} %d\n", metric, label, latency.Count)
+		fmt.Fprintf(&buf, "%s_sum{cache=%s} %s\n", metric, label, strconv.FormatFloat(latency.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(&buf, "%s_count{cache=%s} %d\n", metric, label, latency.Count)
+	}
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics so the collector can be mounted on /metrics.
+func (p *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+func (p *PrometheusCollector) metricName(name string) string {
+	if p.namespace == "" {
+		return "cache_" + name
+	}
+	return p.namespace + "_cache_" + name
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
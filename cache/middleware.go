@@ -0,0 +1,197 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Middleware 装饰一个 Cache，返回新的 Cache
+// 可以在 next 前后插入逻辑，例如日志、链路追踪、统计、key 前缀、容量限制
+type Middleware func(next Cache) Cache
+
+// Chain 按照声明顺序组合 middlewares，第一个 middleware 在最外层
+// Chain(c, a, b) 等价于 a(b(c))，请求依次经过 a、b 最后到达 c
+func Chain(c Cache, middlewares ...Middleware) Cache {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		c = middlewares[i](c)
+	}
+	return c
+}
+
+// CacheBuilder 组合 middleware，顺序与 Chain 相同：先 Use 的在外层
+// 只能 Build 一次：MaxMemMiddleware、MetricsMiddleware 等会接管 base 的 OnEvicted 回调，
+// 在同一个 base 上再次组合会替换掉上一条链路的回调
+type CacheBuilder struct {
+	base        Cache
+	middlewares []Middleware
+	built       bool
+}
+
+func NewCacheBuilder(base Cache) *CacheBuilder {
+	return &CacheBuilder{base: base}
+}
+
+// Use appends middlewares, earlier middlewares wrap later ones.
+func (b *CacheBuilder) Use(middlewares ...Middleware) *CacheBuilder {
+	b.middlewares = append(b.middlewares, middlewares...)
+	return b
+}
+
+// Build returns the composed Cache, it panics when called more than once.
+func (b *CacheBuilder) Build() Cache {
+	if b.built {
+		panic("cache builder: Build called more than once on the same base cache")
+	}
+	b.built = true
+	return Chain(b.base, b.middlewares...)
+}
+
+// Operation Cache 的操作名，用于 Hook
+type Operation string
+
+const (
+	OpGet           Operation = "get"
+	OpSet           Operation = "set"
+	OpDelete        Operation = "delete"
+	OpExists        Operation = "exists"
+	OpLoadAndDelete Operation = "load_and_delete"
+)
+
+// Hook 在操作开始前调用，返回的 ctx 会传给 next，返回的函数在操作结束后以操作的错误调用
+// 链路追踪可以在这里开启 span 并放入 ctx，Exists 结束时 err 为 nil
+type Hook func(ctx context.Context, op Operation, key string) (context.Context, func(err error))
+
+// HookMiddleware 在每个操作前后调用 hook
+func HookMiddleware(hook Hook) Middleware {
+	return func(next Cache) Cache {
+		return &hookCache{next: next, hook: hook}
+	}
+}
+
+// LoggingMiddleware 记录每个操作的耗时，未命中与成功为 Debug 级别，其他错误为 Error 级别
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return HookMiddleware(func(ctx context.Context, op Operation, key string) (context.Context, func(err error)) {
+		start := time.Now()
+		return ctx, func(err error) {
+			attrs := []slog.Attr{
+				slog.String("op", string(op)),
+				slog.String("key", key),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if err != nil && !errors.Is(err, ErrKeyNotFound) {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				logger.LogAttrs(ctx, slog.LevelError, "cache: operation error", attrs...)
+				return
+			}
+			if err != nil {
+				attrs = append(attrs, slog.Bool("miss", true))
+			}
+			logger.LogAttrs(ctx, slog.LevelDebug, "cache: operation", attrs...)
+		}
+	})
+}
+
+// KeyPrefixMiddleware 为所有 key 加上前缀，OnEvicted 回调中的 key 会去掉前缀
+func KeyPrefixMiddleware(prefix string) Middleware {
+	return func(next Cache) Cache {
+		return &prefixCache{next: next, prefix: prefix}
+	}
+}
+
+// MaxMemMiddleware 限制 next 的总内存，见 MaxMemCache
+func MaxMemMiddleware(max int64) Middleware {
+	return func(next Cache) Cache {
+		return NewMaxMemCache(max, next)
+	}
+}
+
+// MetricsMiddleware 统计 next 的指标，并以 name 注册到 collector
+func MetricsMiddleware(collector *PrometheusCollector, name string, opts ...MetricsCacheOption) Middleware {
+	return func(next Cache) Cache {
+		c := NewMetricsCache(next, opts...)
+		collector.Register(name, c)
+		return c
+	}
+}
+
+type hookCache struct {
+	next Cache
+	hook Hook
+}
+
+func (c *hookCache) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, done := c.hook(ctx, OpGet, key)
+	value, err := c.next.Get(ctx, key)
+	done(err)
+	return value, err
+}
+
+func (c *hookCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	ctx, done := c.hook(ctx, OpSet, key)
+	err := c.next.Set(ctx, key, value, expiration)
+	done(err)
+	return err
+}
+
+func (c *hookCache) Delete(ctx context.Context, key string) error {
+	ctx, done := c.hook(ctx, OpDelete, key)
+	err := c.next.Delete(ctx, key)
+	done(err)
+	return err
+}
+
+func (c *hookCache) Exists(ctx context.Context, key string) bool {
+	ctx, done := c.hook(ctx, OpExists, key)
+	ok := c.next.Exists(ctx, key)
+	done(nil)
+	return ok
+}
+
+func (c *hookCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	ctx, done := c.hook(ctx, OpLoadAndDelete, key)
+	value, err := c.next.LoadAndDelete(ctx, key)
+	done(err)
+	return value, err
+}
+
+func (c *hookCache) OnEvicted(fn func(key string, value []byte)) {
+	c.next.OnEvicted(fn)
+}
+
+type prefixCache struct {
+	next   Cache
+	prefix string
+}
+
+func (c *prefixCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.next.Get(ctx, c.prefix+key)
+}
+
+func (c *prefixCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return c.next.Set(ctx, c.prefix+key, value, expiration)
+}
+
+func (c *prefixCache) Delete(ctx context.Context, key string) error {
+	return c.next.Delete(ctx, c.prefix+key)
+}
+
+func (c *prefixCache) Exists(ctx context.Context, key string) bool {
+	return c.next.Exists(ctx, c.prefix+key)
+}
+
+func (c *prefixCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	return c.next.LoadAndDelete(ctx, c.prefix+key)
+}
+
+func (c *prefixCache) OnEvicted(fn func(key string, value []byte)) {
+	if fn == nil {
+		c.next.OnEvicted(nil)
+		return
+	}
+	c.next.OnEvicted(func(key string, value []byte) {
+		fn(strings.TrimPrefix(key, c.prefix), value)
+	})
+}
@@ -0,0 +1,156 @@
+package _cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return HookMiddleware(func(ctx context.Context, op Operation, key string) (context.Context, func(err error)) {
+			calls = append(calls, name+" before "+string(op))
+			return ctx, func(err error) {
+				calls = append(calls, name+" after "+string(op))
+			}
+		})
+	}
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := NewCacheBuilder(local).Use(record("a"), record("b")).Use(record("c")).Build()
+
+	_, err := c.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, []string{
+		"a before get", "b before get", "c before get",
+		"c after get", "b after get", "a after get",
+	}, calls)
+}
+
+func TestCacheBuilder_BuildOnce(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	b := NewCacheBuilder(local).Use(MaxMemMiddleware(100))
+	b.Build()
+	// 第二条链路会替换第一条链路在 local 上的 OnEvicted 回调
+	assert.Panics(t, func() {
+		b.Build()
+	})
+}
+
+type ctxKey struct{}
+
+func TestHookMiddleware(t *testing.T) {
+	var errs []error
+	var seen []any
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := Chain(local,
+		HookMiddleware(func(ctx context.Context, op Operation, key string) (context.Context, func(err error)) {
+			// 例如开启一个 span 放入 ctx
+			return context.WithValue(ctx, ctxKey{}, string(op)+":"+key), func(err error) {
+				errs = append(errs, err)
+			}
+		}),
+		HookMiddleware(func(ctx context.Context, op Operation, key string) (context.Context, func(err error)) {
+			seen = append(seen, ctx.Value(ctxKey{}))
+			return ctx, func(err error) {}
+		}),
+	)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), 0))
+	assert.True(t, c.Exists(ctx, "key"))
+	_, err := c.LoadAndDelete(ctx, "key")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, c.Delete(ctx, "key"))
+
+	assert.Equal(t, []any{"set:key", "exists:key", "load_and_delete:key", "get:key", "delete:key"}, seen)
+	require.Len(t, errs, 5)
+	assert.ErrorIs(t, errs[3], ErrKeyNotFound)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := Chain(local, LoggingMiddleware(logger))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), 0))
+	_, _ = c.Get(ctx, "not-exist")
+
+	closed := NewLocalCache(time.Minute)
+	require.NoError(t, closed.Close())
+	_, err := Chain(closed, LoggingMiddleware(logger)).Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrCacheClosed)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "level=DEBUG")
+	assert.Contains(t, lines[0], "op=set key=key")
+	assert.Contains(t, lines[1], "op=get key=not-exist")
+	assert.Contains(t, lines[1], "miss=true")
+	assert.Contains(t, lines[2], "level=ERROR")
+	assert.Contains(t, lines[2], "cache closed")
+}
+
+func TestKeyPrefixMiddleware(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := Chain(local, KeyPrefixMiddleware("user:"))
+	var evicted []string
+	c.OnEvicted(func(key string, value []byte) {
+		evicted = append(evicted, key)
+	})
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "1", []byte("value"), 0))
+	assert.True(t, local.Exists(ctx, "user:1"))
+	assert.True(t, c.Exists(ctx, "1"))
+	val, err := c.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+	require.NoError(t, c.Delete(ctx, "1"))
+	assert.Equal(t, []string{"1"}, evicted)
+}
+
+func TestCacheBuilder(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	collector := NewPrometheusCollector("")
+	c := NewCacheBuilder(local).
+		Use(MetricsMiddleware(collector, "users")).
+		Use(KeyPrefixMiddleware("user:")).
+		Use(MaxMemMiddleware(20)).
+		Build()
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "2", []byte("value2"), 0))
+	// 两个键值对大小为 2*(len("user:1")+len("value1")) = 24 > 20，淘汰 key 1
+	_, err := c.Get(ctx, "1")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.True(t, local.Exists(ctx, "user:2"))
+
+	err = c.Set(ctx, "3", bytes.Repeat([]byte("v"), 20), 0)
+	var tooLarge *ValueTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, "user:3", tooLarge.Key)
+
+	var buf strings.Builder
+	_, err = collector.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `cache_sets_total{cache="users"} 2`)
+	assert.Contains(t, buf.String(), `cache_misses_total{cache="users"} 1`)
+	assert.Contains(t, buf.String(), `cache_evictions_total{cache="users"} 1`)
+}
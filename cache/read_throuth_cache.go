@@ -1,40 +1,111 @@
 package _cache
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"time"
 
+	"github.com/LXJ0000/go-combat/clock"
 	"golang.org/x/sync/singleflight"
 )
 
+// defaultLoadTimeout 后台加载不再受请求 ctx 控制，使用独立的超时时间
+const defaultLoadTimeout = 3 * time.Second
+
+// negativeValue 代表 key 在数据源中不存在的空值标记
+var negativeValue = []byte("\x00_cache:negative\x00")
+
+// IsNegativeValue 判断直接从底层 Cache 读到的值是否是 ReadThroughCache 写入的空值标记
+func IsNegativeValue(value []byte) bool {
+	return bytes.Equal(value, negativeValue)
+}
+
+type ReadThroughCacheOption func(*ReadThroughCache)
+
+// WithNegativeExpiration LoadFunc 返回 ErrKeyNotFound 时缓存一个空值标记，防止缓存穿透
+// 空值标记保存在底层 Cache 中，绕过 ReadThroughCache 直接读取底层 Cache（例如 LocalCache 的 Scan、Range、Snapshot）会读到它，
+// 可以使用 IsNegativeValue 过滤
+func WithNegativeExpiration(expiration time.Duration) ReadThroughCacheOption {
+	return func(c *ReadThroughCache) {
+		c.NegativeExpiration = expiration
+	}
+}
+
+// WithReadThroughJitter 写入缓存的过期时间随机浮动 ±jitter（例如 0.1 代表 ±10%），避免同时加载的 key 同时过期
+func WithReadThroughJitter(jitter float64) ReadThroughCacheOption {
+	return func(c *ReadThroughCache) {
+		c.Jitter = jitter
+	}
+}
+
+// WithLoadTimeout 后台加载（GetAsync、singleflight 等）使用的超时时间，默认 3s
+func WithLoadTimeout(timeout time.Duration) ReadThroughCacheOption {
+	return func(c *ReadThroughCache) {
+		c.LoadTimeout = timeout
+	}
+}
+
+// WithReadThroughClock 指定时钟，后台加载的超时由它决定，测试中可以传入 clock.FakeClock
+func WithReadThroughClock(clock clock.Clock) ReadThroughCacheOption {
+	return func(c *ReadThroughCache) {
+		c.clock = clock
+	}
+}
+
+// WithLoadManyFunc 批量加载，GetMany 未命中的 key 通过一次调用加载
+func WithLoadManyFunc(loadMany func(ctx context.Context, keys []string) (map[string][]byte, error)) ReadThroughCacheOption {
+	return func(c *ReadThroughCache) {
+		c.LoadManyFunc = loadMany
+	}
+}
+
 // ReadThroughCache 必须实现 LoadFunc 以及 Expiration
 type ReadThroughCache struct {
 	Cache
 	LoadFunc   func(ctx context.Context, key string) ([]byte, error)
 	Expiration time.Duration
-	g          *singleflight.Group
+	// LoadManyFunc 可选，返回结果中没有的 key 视为不存在，为 nil 时 GetMany 逐个调用 LoadFunc
+	LoadManyFunc func(ctx context.Context, keys []string) (map[string][]byte, error)
+	// NegativeExpiration 大于 0 时缓存 key 不存在的结果，期间 Get 直接返回 ErrNegativeCached
+	// 一般比 Expiration 短，Set 会覆盖空值标记
+	// 底层 Cache 中保存的是空值标记，只有通过 ReadThroughCache 读取时才会被识别，参考 IsNegativeValue
+	NegativeExpiration time.Duration
+	// Jitter 大于 0 时 Expiration 随机浮动 ±Jitter
+	Jitter float64
+	// LoadTimeout 后台加载的超时时间，为 0 时使用 defaultLoadTimeout
+	LoadTimeout time.Duration
+	// g 零值可用，直接构造 ReadThroughCache{} 也能使用 GetWithSingleflight
+	g     singleflight.Group
+	clock clock.Clock
 }
 
-func NewReadThroughCache(cache Cache, loadFunc func(ctx context.Context, key string) ([]byte, error), expiration time.Duration) *ReadThroughCache {
-	return &ReadThroughCache{
+func NewReadThroughCache(cache Cache, loadFunc func(ctx context.Context, key string) ([]byte, error), expiration time.Duration, opts ...ReadThroughCacheOption) *ReadThroughCache {
+	c := &ReadThroughCache{
 		Cache:      cache,
 		LoadFunc:   loadFunc,
 		Expiration: expiration,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Get synchronization
 func (c *ReadThroughCache) Get(ctx context.Context, key string) ([]byte, error) {
-	value, err := c.Cache.Get(ctx, key)
+	value, err := c.getCached(ctx, key)
 	if err != nil {
-		if errors.Is(err, errKeyNotFound) {
-			value, err = c.LoadFunc(ctx, key)
+		if c.shouldLoad(err) {
+			value, err = c.load(ctx, key)
 			if err != nil {
+				c.setNegative(ctx, key, err)
 				return nil, err
 			}
-			if err := c.Cache.Set(ctx, key, value, c.Expiration); err != nil {
+			if err := c.Cache.Set(ctx, key, value, c.expiration()); err != nil {
 				slog.Error("read throuth cache: set data error", slog.String("key", key), slog.String("error", err.Error()))
 			}
 			return value, nil
@@ -45,16 +116,21 @@ func (c *ReadThroughCache) Get(ctx context.Context, key string) ([]byte, error)
 }
 
 // GetAsync asynchronous
+// 未命中时立即返回错误，在后台加载数据，后台加载使用独立的 ctx，不会因为请求结束而被取消
 func (c *ReadThroughCache) GetAsync(ctx context.Context, key string) ([]byte, error) {
-	value, err := c.Cache.Get(ctx, key)
+	value, err := c.getCached(ctx, key)
 	if err != nil {
-		if errors.Is(err, errKeyNotFound) {
+		if c.shouldLoad(err) {
 			go func() {
-				value, err = c.LoadFunc(ctx, key)
+				ctx, cancel := c.detach(ctx)
+				defer cancel()
+				value, err := c.load(ctx, key)
 				if err != nil {
 					slog.Error("read throuth cache: load data error", slog.String("key", key), slog.String("error", err.Error()))
+					c.setNegative(ctx, key, err)
+					return
 				}
-				if err := c.Cache.Set(ctx, key, value, c.Expiration); err != nil {
+				if err := c.Cache.Set(ctx, key, value, c.expiration()); err != nil {
 					slog.Error("read throuth cache: set data error", slog.String("key", key), slog.String("error", err.Error()))
 				}
 			}()
@@ -65,16 +141,20 @@ func (c *ReadThroughCache) GetAsync(ctx context.Context, key string) ([]byte, er
 }
 
 // GetAsyncPartial asynchronous
+// 同步加载数据，在后台写入缓存
 func (c *ReadThroughCache) GetAsyncPartial(ctx context.Context, key string) ([]byte, error) {
-	value, err := c.Cache.Get(ctx, key)
+	value, err := c.getCached(ctx, key)
 	if err != nil {
-		if errors.Is(err, errKeyNotFound) {
-			value, err = c.LoadFunc(ctx, key)
+		if c.shouldLoad(err) {
+			value, err = c.load(ctx, key)
 			if err != nil {
+				c.setNegative(ctx, key, err)
 				return nil, err
 			}
 			go func() {
-				if err := c.Cache.Set(ctx, key, value, c.Expiration); err != nil {
+				ctx, cancel := c.detach(ctx)
+				defer cancel()
+				if err := c.Cache.Set(ctx, key, value, c.expiration()); err != nil {
 					slog.Error("read throuth cache: set data error", slog.String("key", key), slog.String("error", err.Error()))
 				}
 			}()
@@ -86,23 +166,235 @@ func (c *ReadThroughCache) GetAsyncPartial(ctx context.Context, key string) ([]b
 }
 
 // GetWithSingleflight
+// 同一个 key 同时只有一个加载，加载结果由所有等待的请求共享
+// 加载使用独立的 ctx，某个请求被取消只会让该请求提前返回，不会影响其他请求
 func (c *ReadThroughCache) GetWithSingleflight(ctx context.Context, key string) ([]byte, error) {
-	value, err := c.Cache.Get(ctx, key)
+	value, err := c.getCached(ctx, key)
 	if err != nil {
-		if errors.Is(err, errKeyNotFound) {
-			val, err, _ := c.g.Do(key, func() (any, error) {
-				value, err := c.LoadFunc(ctx, key)
+		if c.shouldLoad(err) {
+			ch := c.g.DoChan(key, func() (any, error) {
+				ctx, cancel := c.detach(ctx)
+				defer cancel()
+				value, err := c.load(ctx, key)
 				if err != nil {
+					c.setNegative(ctx, key, err)
 					return nil, err
 				}
-				if err := c.Cache.Set(ctx, key, value, c.Expiration); err != nil {
+				if err := c.Cache.Set(ctx, key, value, c.expiration()); err != nil {
 					slog.Error("read throuth cache: set data error", slog.String("key", key), slog.String("error", err.Error()))
 				}
 				return value, nil
 			})
-			return val.([]byte), err
+			return c.wait(ctx, ch)
 		}
 		return nil, err
 	}
 	return value, nil
 }
+
+// GetMany returns the values of the keys that exist, loading all misses in one batch.
+// Cache 实现了 BatchCache 时使用 MGet/MSet，否则逐个读写
+func (c *ReadThroughCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values, err := c.mget(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	misses := make([]string, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		misses = append(misses, key)
+	}
+	// 命中空值标记的 key 不需要加载，也不返回
+	for key, value := range values {
+		if c.isNegative(value) {
+			delete(values, key)
+		}
+	}
+	if len(misses) == 0 {
+		return values, nil
+	}
+
+	loaded, err := c.loadMany(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.mset(ctx, loaded); err != nil {
+		slog.Error("read throuth cache: set data error", slog.Int("keys", len(loaded)), slog.String("error", err.Error()))
+	}
+	for _, key := range misses {
+		value, ok := loaded[key]
+		if !ok {
+			c.setNegative(ctx, key, ErrKeyNotFound)
+			continue
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Exists checks if the given key exists in the cache, cached "not found" results do not count.
+func (c *ReadThroughCache) Exists(ctx context.Context, key string) bool {
+	if c.NegativeExpiration <= 0 {
+		return c.Cache.Exists(ctx, key)
+	}
+	_, err := c.getCached(ctx, key)
+	return err == nil
+}
+
+// LoadAndDelete returns the value for the given key and deletes it, a cached "not found" result is deleted and reported as ErrKeyNotFound.
+func (c *ReadThroughCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.LoadAndDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if c.isNegative(value) {
+		return nil, fmt.Errorf("read through cache: %w, key: %s", ErrKeyNotFound, key)
+	}
+	return value, nil
+}
+
+// getCached 读取缓存，命中空值标记时返回 ErrNegativeCached
+func (c *ReadThroughCache) getCached(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if c.isNegative(value) {
+		return nil, fmt.Errorf("read through cache: %w, key: %s", ErrNegativeCached, key)
+	}
+	return value, nil
+}
+
+func (c *ReadThroughCache) isNegative(value []byte) bool {
+	return c.NegativeExpiration > 0 && IsNegativeValue(value)
+}
+
+// shouldLoad 缓存未命中且不是空值标记时才需要调用 LoadFunc
+func (c *ReadThroughCache) shouldLoad(err error) bool {
+	return errors.Is(err, ErrKeyNotFound) && !errors.Is(err, ErrNegativeCached)
+}
+
+// setNegative LoadFunc 返回 ErrKeyNotFound 时缓存空值标记
+func (c *ReadThroughCache) setNegative(ctx context.Context, key string, err error) {
+	if c.NegativeExpiration <= 0 || !errors.Is(err, ErrKeyNotFound) {
+		return
+	}
+	if err := c.Cache.Set(ctx, key, negativeValue, c.NegativeExpiration); err != nil {
+		slog.Error("read throuth cache: set negative error", slog.String("key", key), slog.String("error", err.Error()))
+	}
+}
+
+func (c *ReadThroughCache) mget(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if bc, ok := c.Cache.(BatchCache); ok {
+		return bc.MGet(ctx, keys)
+	}
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		value, err := c.Cache.Get(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// mset 设置了 Jitter 时逐个写入，保证每个 key 的过期时间不同
+func (c *ReadThroughCache) mset(ctx context.Context, entries map[string][]byte) error {
+	if bc, ok := c.Cache.(BatchCache); ok && c.Jitter <= 0 {
+		return bc.MSet(ctx, entries, c.Expiration)
+	}
+	var errs []error
+	for key, value := range entries {
+		if err := c.Cache.Set(ctx, key, value, c.expiration()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loadMany 优先使用 LoadManyFunc，否则逐个调用 LoadFunc，ErrKeyNotFound 的 key 不出现在结果中
+func (c *ReadThroughCache) loadMany(ctx context.Context, keys []string) (values map[string][]byte, err error) {
+	if c.LoadManyFunc != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("read through cache: load many func panic: %v", r)
+			}
+		}()
+		return c.LoadManyFunc(ctx, keys)
+	}
+	values = make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		value, err := c.load(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// wait 等待 singleflight 的结果，ctx 被取消时提前返回
+func (c *ReadThroughCache) wait(ctx context.Context, ch <-chan singleflight.Result) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+// load 调用 LoadFunc，并将 LoadFunc 中的 panic 转换为错误
+func (c *ReadThroughCache) load(ctx context.Context, key string) (value []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("read through cache: load func panic: %v, key: %s", r, key)
+		}
+	}()
+	return c.LoadFunc(ctx, key)
+}
+
+// detach 返回不随 ctx 取消、带有 LoadTimeout 超时的 ctx，ctx 中的值依旧保留
+func (c *ReadThroughCache) detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.LoadTimeout
+	if timeout <= 0 {
+		timeout = defaultLoadTimeout
+	}
+	return clock.WithTimeout(context.WithoutCancel(ctx), c.getClock(), timeout)
+}
+
+// getClock 兼容直接构造的 ReadThroughCache{}
+func (c *ReadThroughCache) getClock() clock.Clock {
+	if c.clock == nil {
+		return clock.New()
+	}
+	return c.clock
+}
+
+// expiration 返回加上随机浮动之后的过期时间
+func (c *ReadThroughCache) expiration() time.Duration {
+	return jitterExpiration(c.Expiration, c.Jitter)
+}
+
+func jitterExpiration(expiration time.Duration, jitter float64) time.Duration {
+	if expiration <= 0 || jitter <= 0 {
+		return expiration
+	}
+	return time.Duration(float64(expiration) * (1 + (rand.Float64()*2-1)*jitter))
+}
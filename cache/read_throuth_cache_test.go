@@ -0,0 +1,331 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadThroughCache_Negative(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Hour, WithClock(fake))
+	defer local.Close()
+	var loads atomic.Int32
+	c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		loads.Add(1)
+		return nil, ErrKeyNotFound
+	}, time.Minute, WithNegativeExpiration(time.Second))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		get  func(ctx context.Context, key string) ([]byte, error)
+	}{
+		{name: "get", get: c.Get},
+		{name: "get async partial", get: c.GetAsyncPartial},
+		{name: "get with singleflight", get: c.GetWithSingleflight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads.Store(0)
+			key := tt.name
+			_, err := tt.get(ctx, key)
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+			assert.NotErrorIs(t, err, ErrNegativeCached)
+
+			// 命中空值标记，不再调用 LoadFunc
+			_, err = tt.get(ctx, key)
+			assert.ErrorIs(t, err, ErrNegativeCached)
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+			assert.False(t, c.Exists(ctx, key))
+			assert.Equal(t, int32(1), loads.Load())
+
+			// 底层缓存中保存的是空值标记
+			raw, err := local.Get(ctx, key)
+			require.NoError(t, err)
+			assert.True(t, IsNegativeValue(raw))
+			// LoadAndDelete 删除空值标记，不会返回它
+			_, err = c.LoadAndDelete(ctx, key)
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+			assert.False(t, local.Exists(ctx, key))
+			_, err = tt.get(ctx, key)
+			assert.NotErrorIs(t, err, ErrNegativeCached)
+			assert.Equal(t, int32(2), loads.Load())
+
+			// Set 覆盖空值标记
+			require.NoError(t, c.Set(ctx, key, []byte("value"), time.Minute))
+			val, err := tt.get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("value"), val)
+			require.NoError(t, c.Delete(ctx, key))
+		})
+	}
+
+	// 空值标记过期之后重新加载
+	loads.Store(0)
+	_, err := c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	fake.Advance(2 * time.Second)
+	_, err = c.Get(ctx, "key")
+	assert.NotErrorIs(t, err, ErrNegativeCached)
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestReadThroughCache_NegativeOtherError(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	loadErr := errors.New("db down")
+	var loads atomic.Int32
+	c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		loads.Add(1)
+		return nil, loadErr
+	}, time.Minute, WithNegativeExpiration(time.Second))
+	ctx := context.Background()
+
+	// 只有不存在的结果会被缓存
+	for i := 0; i < 2; i++ {
+		_, err := c.Get(ctx, "key")
+		assert.ErrorIs(t, err, loadErr)
+	}
+	assert.Equal(t, int32(2), loads.Load())
+	assert.False(t, local.Exists(ctx, "key"))
+}
+
+func TestReadThroughCache_Concurrent(t *testing.T) {
+	tests := []struct {
+		name string
+		get  func(c *ReadThroughCache, ctx context.Context, key string) ([]byte, error)
+		// async 未命中时立即返回 ErrKeyNotFound
+		async bool
+		// singleflight 只会加载一次
+		singleflight bool
+	}{
+		{name: "get", get: (*ReadThroughCache).Get},
+		{name: "get async", get: (*ReadThroughCache).GetAsync, async: true},
+		{name: "get async partial", get: (*ReadThroughCache).GetAsyncPartial},
+		{name: "get with singleflight", get: (*ReadThroughCache).GetWithSingleflight, singleflight: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewLocalCache(time.Minute)
+			defer local.Close()
+			var loads atomic.Int32
+			c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+				loads.Add(1)
+				time.Sleep(50 * time.Millisecond)
+				return []byte("value"), nil
+			}, time.Minute)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					val, err := tt.get(c, context.Background(), "key")
+					if tt.async && err != nil {
+						assert.ErrorIs(t, err, ErrKeyNotFound)
+						return
+					}
+					assert.NoError(t, err)
+					assert.Equal(t, []byte("value"), val)
+				}()
+			}
+			wg.Wait()
+			assert.Eventually(t, func() bool {
+				val, err := local.Get(context.Background(), "key")
+				return err == nil && string(val) == "value"
+			}, time.Second, 10*time.Millisecond)
+			if tt.singleflight {
+				assert.Equal(t, int32(1), loads.Load())
+			}
+		})
+	}
+}
+
+func TestReadThroughCache_Panic(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	var loads atomic.Int32
+	c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		loads.Add(1)
+		panic("boom")
+	}, time.Minute)
+	ctx := context.Background()
+
+	for _, get := range []func(ctx context.Context, key string) ([]byte, error){
+		c.Get, c.GetAsyncPartial, c.GetWithSingleflight,
+	} {
+		_, err := get(ctx, "key")
+		assert.ErrorContains(t, err, "load func panic: boom")
+	}
+	// 后台加载的 panic 不会导致进程崩溃
+	_, err := c.GetAsync(ctx, "key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Eventually(t, func() bool {
+		return loads.Load() == 4
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, local.Exists(ctx, "key"))
+}
+
+func TestReadThroughCache_Detached(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return []byte(key), nil
+		}
+	}, time.Minute, WithLoadTimeout(time.Second))
+
+	// 请求结束之后后台加载依旧可以完成
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := c.GetAsync(ctx, "async")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	cancel()
+	assert.Eventually(t, func() bool {
+		return local.Exists(context.Background(), "async")
+	}, time.Second, 10*time.Millisecond)
+
+	// 第一个请求被取消，不影响等待同一个加载的其他请求
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := c.GetWithSingleflight(ctx, "singleflight")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}()
+	val, err := c.GetWithSingleflight(context.Background(), "singleflight")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("singleflight"), val)
+	wg.Wait()
+}
+
+func TestReadThroughCache_LoadTimeout(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	fake := clock.NewFake(time.Now())
+	started := make(chan struct{})
+	c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, time.Minute, WithLoadTimeout(time.Second), WithReadThroughClock(fake))
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.GetWithSingleflight(context.Background(), "key")
+		errCh <- err
+	}()
+	<-started
+	fake.Advance(999 * time.Millisecond)
+	select {
+	case err := <-errCh:
+		t.Fatalf("load returned before timeout: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	fake.Advance(time.Millisecond)
+	assert.ErrorIs(t, <-errCh, context.DeadlineExceeded)
+}
+
+func TestReadThroughCache_ZeroValue(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	// 直接构造也可以使用 singleflight
+	c := &ReadThroughCache{
+		Cache: local,
+		LoadFunc: func(ctx context.Context, key string) ([]byte, error) {
+			return []byte("value"), nil
+		},
+		Expiration: time.Minute,
+	}
+	val, err := c.GetWithSingleflight(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+}
+
+func TestReadThroughCache_GetMany(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []ReadThroughCacheOption
+		wantLoads int32
+	}{
+		{
+			name: "load many",
+			opts: []ReadThroughCacheOption{WithLoadManyFunc(func(ctx context.Context, keys []string) (map[string][]byte, error) {
+				values := make(map[string][]byte, len(keys))
+				for _, key := range keys {
+					if key != "not-exist" {
+						values[key] = []byte("db:" + key)
+					}
+				}
+				return values, nil
+			})},
+			wantLoads: 0,
+		},
+		{
+			name:      "fallback to load func",
+			wantLoads: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewLocalCache(time.Minute)
+			defer local.Close()
+			var loads atomic.Int32
+			opts := append([]ReadThroughCacheOption{WithNegativeExpiration(time.Minute)}, tt.opts...)
+			c := NewReadThroughCache(local, func(ctx context.Context, key string) ([]byte, error) {
+				loads.Add(1)
+				if key == "not-exist" {
+					return nil, ErrKeyNotFound
+				}
+				return []byte("db:" + key), nil
+			}, time.Minute, opts...)
+			ctx := context.Background()
+			require.NoError(t, local.Set(ctx, "key1", []byte("cached"), 0))
+
+			values, err := c.GetMany(ctx, []string{"key1", "key2", "key3", "not-exist"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string][]byte{
+				"key1": []byte("cached"),
+				"key2": []byte("db:key2"),
+				"key3": []byte("db:key3"),
+			}, values)
+			assert.Equal(t, tt.wantLoads, loads.Load())
+
+			// 加载的数据写入缓存，不存在的 key 命中空值标记
+			values, err = c.GetMany(ctx, []string{"key2", "key3", "not-exist"})
+			require.NoError(t, err)
+			assert.Len(t, values, 2)
+			assert.Equal(t, tt.wantLoads, loads.Load())
+			_, err = c.Get(ctx, "not-exist")
+			assert.ErrorIs(t, err, ErrNegativeCached)
+		})
+	}
+}
+
+func TestReadThroughCache_GetManyError(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	loadErr := errors.New("db down")
+	c := NewReadThroughCache(local, nil, time.Minute, WithLoadManyFunc(func(ctx context.Context, keys []string) (map[string][]byte, error) {
+		return nil, loadErr
+	}))
+	_, err := c.GetMany(context.Background(), []string{"key"})
+	assert.ErrorIs(t, err, loadErr)
+
+	c.LoadManyFunc = func(ctx context.Context, keys []string) (map[string][]byte, error) {
+		panic("boom")
+	}
+	_, err = c.GetMany(context.Background(), []string{"key"})
+	assert.ErrorContains(t, err, "load many func panic: boom")
+}
@@ -2,14 +2,19 @@ package _cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	_ "github.com/golang/mock/mockgen/model"
+	"github.com/redis/go-redis/v9"
 )
 
+var _ BatchCache = &RedisCache{}
+
 type RedisCache struct {
-	cmd redis.Cmdable
+	cmd   redis.Cmdable
+	evict func(key string, value []byte)
 }
 
 func NewRedisCache(cmd redis.Cmdable) *RedisCache {
@@ -19,18 +24,30 @@ func NewRedisCache(cmd redis.Cmdable) *RedisCache {
 }
 
 // Get returns the value for the given key.
-func (c *RedisCache) Get(ctx context.Context, key string) (any, error) {
-	return c.cmd.Get(ctx, key).Result()
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.cmd.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("redis cache: %w, key: %s", ErrKeyNotFound, key)
+	}
+	return val, err
 }
 
 // Set sets the value for the given key with an optional expiration time.
-func (c *RedisCache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
 	return c.cmd.Set(ctx, key, value, expiration).Err()
 }
 
 // Delete deletes the value for the given key.
+// 设置了 OnEvicted 时使用 GETDEL 拿到被删除的值
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
-	return c.cmd.Del(ctx, key).Err()
+	if c.evict == nil {
+		return c.cmd.Del(ctx, key).Err()
+	}
+	_, err := c.LoadAndDelete(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	return err
 }
 
 // Exists checks if the given key exists in the cache.
@@ -39,6 +56,84 @@ func (c *RedisCache) Exists(ctx context.Context, key string) bool {
 }
 
 // LoadAndDelete returns the value for the given key and deletes it from the cache.
-func (c *RedisCache) LoadAndDelete(ctx context.Context, key string) (any, error) {
-	return c.cmd.GetDel(ctx, key).Result()
-}
\ No newline at end of file
+func (c *RedisCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.cmd.GetDel(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("redis cache: %w, key: %s", ErrKeyNotFound, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.evict != nil {
+		c.evict(key, val)
+	}
+	return val, nil
+}
+
+// MGet returns the values of the keys that exist with a single MGET.
+func (c *RedisCache) MGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+	res, err := c.cmd.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, val := range res {
+		// 不存在的 key 返回 nil
+		if s, ok := val.(string); ok {
+			values[keys[i]] = []byte(s)
+		}
+	}
+	return values, nil
+}
+
+// MSet sets all entries with pipelined SET, MSET does not support expiration.
+func (c *RedisCache) MSet(ctx context.Context, entries map[string][]byte, expiration time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	_, err := c.cmd.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range entries {
+			pipe.Set(ctx, key, value, expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+// MDelete deletes the values for the given keys.
+// 设置了 OnEvicted 时使用 pipeline 执行 GETDEL 拿到被删除的值
+func (c *RedisCache) MDelete(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if c.evict == nil {
+		return c.cmd.Del(ctx, keys...).Err()
+	}
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := c.cmd.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.GetDel(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	for i, cmd := range cmds {
+		val, err := cmd.Bytes()
+		if err != nil {
+			continue
+		}
+		c.evict(keys[i], val)
+	}
+	return nil
+}
+
+// OnEvicted sets the callback function which is called when a key is deleted through this cache.
+// Redis 自身的过期与内存淘汰不会触发回调
+func (c *RedisCache) OnEvicted(fn func(key string, value []byte)) {
+	c.evict = fn
+}
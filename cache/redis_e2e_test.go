@@ -22,17 +22,17 @@ func TestRedisCache_e2e_Set(t *testing.T) {
 		before  func()             // 准备数据
 		after   func(t *testing.T) // 清除数据
 		key     string
-		val     any
+		val     []byte
 		exp     time.Duration
 		wantErr error
-		want    any
+		want    []byte
 	}{
 		{
 			name: "success",
 			key:  "key",
-			val:  "value",
+			val:  []byte("value"),
 			exp:  time.Minute,
-			want: "value",
+			want: []byte("value"),
 			after: func(t *testing.T) {
 				val, err := cmd.Get(context.Background(), "key").Result()
 				require.NoError(t, err)
@@ -64,10 +64,10 @@ func TestRedisCache_e2e_Get(t *testing.T) {
 	cache := NewRedisCache(cmd)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
-	err := cache.Set(ctx, "key", "value", time.Minute)
+	err := cache.Set(ctx, "key", []byte("value"), time.Minute)
 	require.NoError(t, err)
 	val, err := cache.Get(ctx, "key")
 	require.NoError(t, err)
-	require.Equal(t, "value", val)
+	require.Equal(t, []byte("value"), val)
 
 }
@@ -0,0 +1,80 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	_ "embed"
+)
+
+var (
+	//go:embed lua/fair_lock.lua
+	luaFairLock string
+
+	//go:embed lua/fair_unlock.lua
+	luaFairUnLock string
+
+	//go:embed lua/fair_leave.lua
+	luaFairLeave string
+)
+
+// fairLockPollInterval 等待者 BLPOP 的超时时间，BLPOP 的精度为秒
+// 阻塞中的 BLPOP 不会因为 ctx 取消而返回，这也是放弃等待的最大延迟
+const fairLockPollInterval = time.Second
+
+// fairLockKeys 公平锁使用的 key：锁、等待队列、等待者的超时时间、唤醒 list 的前缀
+// 使用 hash tag 保证在 redis cluster 中位于同一个 slot
+func fairLockKeys(key string) []string {
+	return []string{"{" + key + "}:lock", "{" + key + "}:queue", "{" + key + "}:timeout", "{" + key + "}:wake:"}
+}
+
+// FairLock acquires the lock in arrival order, waiting until it is acquired or ctx is done.
+// 等待者进入 redis 中的队列，通过 BLPOP 阻塞在自己的唤醒 list 上，解锁时只唤醒队首的等待者
+// 等待者每隔 fairLockPollInterval 醒来一次刷新排队的超时时间，超过三个周期没有刷新的等待者会被移出队列
+// 锁过期（持有者崩溃）时没有人唤醒，队首最迟在一个周期之后重试
+// 每个等待者阻塞期间占用一个连接，连接池需要大于同时等待的数量，否则解锁需要等待连接
+func (c *Client) FairLock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, opts ...LockOption) (*Lock, error) {
+	value := uuid.New().String()
+	keys := fairLockKeys(key)
+	wake := keys[3] + value
+	for {
+		ctxLock, cancel := context.WithTimeout(ctx, contextTimeout)
+		res, err := c.cmd.Eval(ctxLock, luaFairLock, keys, value, expiration.Milliseconds(), (3 * fairLockPollInterval).Milliseconds()).Int64()
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				slog.Warn("redis lock: fair lock failed with context timeout, retrying...")
+				continue
+			}
+			c.leaveFairLock(keys, value, expiration)
+			return nil, err
+		}
+		if res == 1 {
+			_ = c.cmd.Del(context.Background(), wake).Err()
+			return c.newLock(key, value, expiration, lockScripts{keys: keys, unlock: luaFairUnLock, refresh: luaRefreshExpiration}, opts), nil
+		}
+		// 等待唤醒或者超时之后重试
+		err = c.cmd.BLPop(ctx, fairLockPollInterval, wake).Err()
+		if ctx.Err() != nil {
+			c.leaveFairLock(keys, value, expiration)
+			return nil, ctx.Err()
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			c.leaveFairLock(keys, value, expiration)
+			return nil, err
+		}
+	}
+}
+
+// leaveFairLock 放弃等待时离开队列，避免后面的等待者等到超时
+// 加锁请求出错时可能已经加锁成功，同时释放自己持有的锁
+func (c *Client) leaveFairLock(keys []string, value string, expiration time.Duration) {
+	if err := c.cmd.Eval(context.Background(), luaFairLeave, keys, value, expiration.Milliseconds()).Err(); err != nil {
+		slog.Error("redis lock: leave fair lock queue failed", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,142 @@
+package _cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_FairLockOrder(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	ctx := context.Background()
+	holder, err := c.FairLock(ctx, "key", time.Minute, time.Second)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := c.FairLock(ctx, "key", time.Minute, time.Second)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			assert.NoError(t, lock.UnLock())
+		}()
+		// 等待第 i 个等待者入队之后再启动下一个
+		require.Eventually(t, func() bool {
+			queue, _ := mr.List("{key}:queue")
+			return len(queue) == i+1
+		}, time.Second, time.Millisecond)
+	}
+
+	// 新来的人排在队尾，放弃等待之后离开队列
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = c.FairLock(timeoutCtx, "key", time.Minute, time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	queue, _ := mr.List("{key}:queue")
+	assert.Len(t, queue, 5)
+
+	start := time.Now()
+	require.NoError(t, holder.UnLock())
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+	// 通过 BLPOP 唤醒，不需要等待轮询周期
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, mr.Exists("{key}:lock"))
+	assert.False(t, mr.Exists("{key}:queue"))
+}
+
+func TestClient_FairLockContention(t *testing.T) {
+	mr := miniredis.RunT(t)
+	// 每个等待者占用一个连接
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), PoolSize: 32})
+	t.Cleanup(func() { _ = rdb.Close() })
+	c := NewClient(rdb)
+	ctx := context.Background()
+	var holders, acquired atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				lock, err := c.FairLock(ctx, "key", 10*time.Second, time.Second)
+				if !assert.NoError(t, err) {
+					return
+				}
+				// 同一时间只有一个持有者
+				assert.Equal(t, int32(1), holders.Add(1))
+				acquired.Add(1)
+				time.Sleep(time.Millisecond)
+				holders.Add(-1)
+				assert.NoError(t, lock.UnLock())
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(100), acquired.Load())
+	assert.False(t, mr.Exists("{key}:lock"))
+	assert.False(t, mr.Exists("{key}:queue"))
+	assert.False(t, mr.Exists("{key}:timeout"))
+}
+
+func TestClient_FairLockCancel(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	holder, err := c.FairLock(context.Background(), "key", time.Minute, time.Second)
+	require.NoError(t, err)
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	errA := make(chan error, 1)
+	go func() {
+		_, err := c.FairLock(ctxA, "key", time.Minute, time.Second)
+		errA <- err
+	}()
+	require.Eventually(t, func() bool {
+		queue, _ := mr.List("{key}:queue")
+		return len(queue) == 1
+	}, time.Second, time.Millisecond)
+	lockB := make(chan *Lock, 1)
+	go func() {
+		lock, err := c.FairLock(context.Background(), "key", time.Minute, time.Second)
+		assert.NoError(t, err)
+		lockB <- lock
+	}()
+	require.Eventually(t, func() bool {
+		queue, _ := mr.List("{key}:queue")
+		return len(queue) == 2
+	}, time.Second, time.Millisecond)
+
+	// A 放弃等待之后离开队列
+	cancelA()
+	select {
+	case err := <-errA:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(3 * time.Second):
+		t.Fatal("waiter not cancelled")
+	}
+	queue, _ := mr.List("{key}:queue")
+	assert.Len(t, queue, 1)
+
+	require.NoError(t, holder.UnLock())
+	select {
+	case lock := <-lockB:
+		require.NotNil(t, lock)
+		require.NoError(t, lock.UnLock())
+	case <-time.After(time.Second):
+		t.Fatal("next waiter not woken")
+	}
+}
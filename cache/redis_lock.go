@@ -3,9 +3,12 @@ package _cache
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/LXJ0000/go-combat/clock"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/sync/singleflight"
@@ -17,6 +20,7 @@ var (
 	ErrLockNotFound = errors.New("redis lock: unlock failed with lock not found")
 	ErrLockFail     = errors.New("redis lock: lock failed")
 	ErrLockRefresh  = errors.New("redis lock: refresh failed")
+	ErrLockLost     = errors.New("redis lock: lock lost")
 
 	//go:embed lua/unlock.lua
 	luaUnLock string
@@ -29,52 +33,108 @@ var (
 )
 
 type Client struct {
-	cmd redis.Cmdable
-	g   singleflight.Group
+	cmd   redis.Cmdable
+	g     singleflight.Group
+	clock clock.Clock
 }
 
-func (c *Client) Lock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, retry RetryStrategy) (*Lock, error) {
-	var ticker *time.Ticker
+type ClientOption func(*Client)
+
+// WithClientClock 指定重试与自动续约使用的时钟
+func WithClientClock(clock clock.Clock) ClientOption {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+func NewClient(cmd redis.Cmdable, opts ...ClientOption) *Client {
+	c := &Client{
+		cmd:   cmd,
+		clock: clock.New(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// getClock 兼容直接构造的 Client{}
+func (c *Client) getClock() clock.Clock {
+	if c.clock == nil {
+		return clock.New()
+	}
+	return c.clock
+}
+
+// LockOption 加锁时的可选配置
+type LockOption func(*Lock)
+
+// WithWatchdog 加锁成功后在后台每隔 expiration/3 自动续约，直到 UnLock
+// 续约失败或者锁被他人持有时关闭 Lost，持有者应当停止依赖锁的操作
+func WithWatchdog() LockOption {
+	return func(l *Lock) {
+		l.watchdog = true
+	}
+}
+
+// WithRefreshTimeout watchdog 单次续约的超时时间，默认为续约间隔的 1/3
+// 需要明显小于续约间隔，一次续约超时之后在锁过期之前还有机会重试
+func WithRefreshTimeout(timeout time.Duration) LockOption {
+	return func(l *Lock) {
+		l.refreshTimeout = timeout
+	}
+}
+
+func (c *Client) Lock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, retry RetryStrategy, opts ...LockOption) (*Lock, error) {
 	value := uuid.New().String() // 唯一标识加锁的人
+	err := c.acquire(ctx, contextTimeout, retry, func(ctx context.Context) (bool, error) {
+		res, err := c.cmd.Eval(ctx, luaLock, []string{key}, value, expiration.Seconds()).Result()
+		return res == "OK", err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.newLock(key, value, expiration, exclusiveScripts(key), opts), nil
+}
+
+// acquire 反复调用 try 直到加锁成功
+// 单次请求超时立即重试，锁被他人持有时按照 retry 的间隔等待，retry 用尽返回 ErrLockFail
+func (c *Client) acquire(ctx context.Context, contextTimeout time.Duration, retry RetryStrategy, try func(ctx context.Context) (bool, error)) error {
+	var ticker clock.Ticker
 	for {
 		ctxLock, cancel := context.WithTimeout(ctx, contextTimeout)
-		res, err := c.cmd.Eval(ctxLock, luaLock, []string{key}, value, expiration.Seconds()).Result()
+		ok, err := try(ctxLock)
 		cancel()
 		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
 				slog.Warn("redis lock: lock failed with context timeout, retrying...")
 				continue
 			}
 			slog.Error("redis lock: lock failed with error", slog.Any("error", err))
-			return nil, err
+			return err
 		}
-		if res == "OK" {
-			return &Lock{
-				cmd:        c.cmd,
-				key:        key,
-				value:      value,
-				expiration: expiration,
-				done:       make(chan struct{}),
-			}, nil
+		if ok {
+			return nil
 		}
 		interval, ok := retry.Next()
 		if !ok {
-			return nil, ErrLockFail
+			return ErrLockFail
 		}
 		if ticker == nil {
-			ticker = time.NewTicker(interval)
+			ticker = c.getClock().NewTicker(interval)
+			defer ticker.Stop()
 		} else {
 			ticker.Reset(interval)
 		}
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		}
 	}
 }
 
-func (c *Client) TryLock(ctx context.Context, key string, expiration time.Duration) (*Lock, error) {
+func (c *Client) TryLock(ctx context.Context, key string, expiration time.Duration, opts ...LockOption) (*Lock, error) {
 	value := uuid.New().String() // 唯一标识加锁的人
 	ok, err := c.cmd.SetNX(ctx, key, value, expiration).Result()
 	if err != nil {
@@ -83,12 +143,38 @@ func (c *Client) TryLock(ctx context.Context, key string, expiration time.Durati
 	if !ok {
 		return nil, ErrLockFail
 	}
-	return &Lock{
+	return c.newLock(key, value, expiration, exclusiveScripts(key), opts), nil
+}
+
+func (c *Client) newLock(key, value string, expiration time.Duration, scripts lockScripts, opts []LockOption) *Lock {
+	l := &Lock{
 		cmd:        c.cmd,
 		key:        key,
 		value:      value,
 		expiration: expiration,
-	}, nil
+		scripts:    scripts,
+		done:       make(chan struct{}),
+		lost:       make(chan struct{}),
+		clock:      c.getClock(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.watchdog {
+		// ticker 在返回之前创建，避免错过第一次续约
+		interval := l.expiration / 3
+		timeout := l.refreshTimeout
+		if timeout <= 0 {
+			timeout = interval / 3
+		}
+		ticker := l.clock.NewTicker(interval)
+		go func() {
+			if err := l.autoRefresh(ticker, timeout); err != nil {
+				slog.Error("redis lock: watchdog refresh failed", slog.String("key", l.key), slog.Any("error", err))
+			}
+		}()
+	}
+	return l
 }
 
 type Lock struct {
@@ -97,14 +183,61 @@ type Lock struct {
 	value      string
 	expiration time.Duration
 	done       chan struct{}
+	clock      clock.Clock
+	watchdog   bool
+	// refreshTimeout watchdog 单次续约的超时时间
+	refreshTimeout time.Duration
+	scripts        lockScripts
+	unlockOnce     sync.Once
+	// lost 续约失败时关闭，lostErr 在关闭之前写入
+	lost     chan struct{}
+	lostOnce sync.Once
+	lostErr  error
 }
 
+// Lost returns a channel that is closed when the lock can no longer be renewed, it is never closed by UnLock.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Err returns why the lock was lost, or nil if it has not been lost.
+func (l *Lock) Err() error {
+	select {
+	case <-l.lost:
+		return l.lostErr
+	default:
+		return nil
+	}
+}
+
+func (l *Lock) markLost(err error) {
+	l.lostOnce.Do(func() {
+		l.lostErr = err
+		if l.lost != nil {
+			close(l.lost)
+		}
+	})
+}
+
+// UnLock releases the lock and stops auto refresh, repeated calls return ErrLockNotFound.
+// 每个 Lock 最多解锁一次，可重入锁重复解锁不会多减一次重入次数
 func (l *Lock) UnLock() error {
-	defer close(l.done)
+	err := ErrLockNotFound
+	l.unlockOnce.Do(func() {
+		if l.done != nil {
+			close(l.done)
+		}
+		err = l.unlock()
+	})
+	return err
+}
+
+func (l *Lock) unlock() error {
 	// 以下步骤必须为原子操作 这里采用 lua 脚本实现
 	// 1. 检查是否为自己加的锁
 	// 2. 解锁
-	cnt, err := l.cmd.Eval(context.Background(), luaUnLock, []string{l.key}, l.value).Int64()
+	scripts := l.getScripts()
+	cnt, err := l.cmd.Eval(context.Background(), scripts.unlock, scripts.keys, l.value, l.expiration.Milliseconds()).Int64()
 	if err != nil {
 		return err
 	}
@@ -115,7 +248,8 @@ func (l *Lock) UnLock() error {
 }
 
 func (l *Lock) Refresh(ctx context.Context) error {
-	cnt, err := l.cmd.Eval(ctx, luaRefreshExpiration, []string{l.key}, l.value, l.expiration.Seconds()).Int64()
+	scripts := l.getScripts()
+	cnt, err := l.cmd.Eval(ctx, scripts.refresh, scripts.keys, l.value, l.expiration.Milliseconds()).Int64()
 	if err != nil {
 		return err
 	}
@@ -125,49 +259,83 @@ func (l *Lock) Refresh(ctx context.Context) error {
 	return nil
 }
 
+// lockScripts 解锁与续约使用的脚本
+// 脚本的参数均为 ARGV[1] value 与 ARGV[2] 过期时间（毫秒），成功时返回 1
+type lockScripts struct {
+	keys    []string
+	unlock  string
+	refresh string
+}
+
+// exclusiveScripts 互斥锁的脚本
+func exclusiveScripts(key string) lockScripts {
+	return lockScripts{keys: []string{key}, unlock: luaUnLock, refresh: luaRefreshExpiration}
+}
+
+// getScripts 兼容直接构造的 Lock{}
+func (l *Lock) getScripts() lockScripts {
+	if l.scripts.unlock == "" {
+		return exclusiveScripts(l.key)
+	}
+	return l.scripts
+}
+
+// AutoRefresh refreshes the lock every interval until UnLock is called or a refresh fails.
+// 续约超时会立即重试，直到上一次续约成功后的过期时间，失败时同时关闭 Lost
 func (l *Lock) AutoRefresh(interval time.Duration, contextTimeout time.Duration) error {
-	var lock *Lock // 成功拿到锁
+	clk := l.clock
+	if clk == nil {
+		clk = clock.New()
+	}
+	return l.autoRefresh(clk.NewTicker(interval), contextTimeout)
+}
+
+func (l *Lock) autoRefresh(ticker clock.Ticker, contextTimeout time.Duration) error {
+	defer ticker.Stop()
+	clk := l.clock
+	if clk == nil {
+		clk = clock.New()
+	}
+	deadline := clk.Now().Add(l.expiration) // 锁在 redis 中的过期时间
 	timeout := make(chan struct{}, 1)
-	ticker := time.NewTicker(interval) // 每10秒刷新一次
 	for {
 		select {
-		case <-ticker.C:
-			ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-			if err := lock.Refresh(ctx); err != nil {
-				if errors.Is(err, context.DeadlineExceeded) {
-					timeout <- struct{}{}
-					cancel()
-					continue // 超时之后会进入到 case <-timeout 逻辑
-				}
-				cancel()
-				return err
-			}
-			cancel()
+		case <-ticker.C():
+		case <-timeout:
+			// 超时重试
 		case <-l.done:
 			return nil // 主动释放锁
-		case <-timeout:
-			// 超时重试机制...
-			ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-			if err := lock.Refresh(ctx); err != nil {
-				if errors.Is(err, context.DeadlineExceeded) {
-					timeout <- struct{}{}
-					cancel()
-					continue // 超时之后会进入到 case <-timeout 逻辑
-				}
-				cancel()
-				return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		err := l.Refresh(ctx)
+		cancel()
+		if err == nil {
+			deadline = clk.Now().Add(l.expiration)
+			continue
+		}
+		select {
+		case <-l.done:
+			return nil // 续约期间已经释放锁
+		default:
+		}
+		// 锁已经不属于自己时立即放弃，其他错误在锁过期之前继续重试
+		if !errors.Is(err, ErrLockRefresh) && clk.Now().Before(deadline) {
+			if errors.Is(err, context.DeadlineExceeded) {
+				timeout <- struct{}{} // 超时立即重试，其他错误等待下一次 tick
 			}
-			cancel()
+			continue
 		}
+		l.markLost(err)
+		return err
 	}
 }
 
-func (c *Client) SingleflightLock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, retry RetryStrategy) (*Lock, error) {
+func (c *Client) SingleflightLock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, retry RetryStrategy, opts ...LockOption) (*Lock, error) {
 	for {
 		var flag bool // 是否自己加的锁
 		result := c.g.DoChan(key, func() (interface{}, error) {
 			flag = true // 加锁成功
-			return c.Lock(ctx, key, expiration, contextTimeout, retry)
+			return c.Lock(ctx, key, expiration, contextTimeout, retry, opts...)
 		})
 		select {
 		case <-ctx.Done():
@@ -183,3 +351,66 @@ func (c *Client) SingleflightLock(ctx context.Context, key string, expiration ti
 		}
 	}
 }
+
+// defaultLockTimeout WithLock 单次加锁请求的默认超时
+const defaultLockTimeout = time.Second
+
+// LockOptions WithLock 的加锁参数
+type LockOptions struct {
+	// Expiration 锁的过期时间，持有期间每隔 Expiration/3 自动续约
+	Expiration time.Duration
+	// Timeout 单次加锁请求的超时，默认 1s
+	Timeout time.Duration
+	// Retry 加锁失败时的重试策略，nil 表示只尝试一次
+	Retry RetryStrategy
+	// Reentrant 使用可重入锁，ctx 中没有 owner 时生成一个并传给 fn，fn 中嵌套的 WithLock 会重入
+	Reentrant bool
+}
+
+// WithLock acquires the lock, runs fn while renewing it and always unlocks afterwards, even if fn panics.
+// fn 的 ctx 在续约失败时立即取消，context.Cause(ctx) 为 ErrLockLost
+// fn 返回错误时返回该错误，否则锁丢失时返回 ErrLockLost，其次返回解锁的错误
+func (c *Client) WithLock(ctx context.Context, key string, opts LockOptions, fn func(ctx context.Context) error) (err error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+	retry := opts.Retry
+	if retry == nil {
+		retry = NewDefaultRetryStrategy(0, 0)
+	}
+	var lock *Lock
+	if opts.Reentrant {
+		if _, ok := LockOwner(ctx); !ok {
+			ctx = WithLockOwner(ctx, uuid.New().String())
+		}
+		lock, err = c.ReentrantLock(ctx, key, opts.Expiration, timeout, retry, WithWatchdog())
+	} else {
+		lock, err = c.Lock(ctx, key, opts.Expiration, timeout, retry, WithWatchdog())
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-lock.Lost():
+			cancel(fmt.Errorf("%w: %w", ErrLockLost, lock.Err()))
+		case <-ctx.Done():
+		}
+	}()
+	defer func() {
+		cancel(nil)
+		unlockErr := lock.UnLock()
+		if err != nil {
+			return
+		}
+		if lostErr := lock.Err(); lostErr != nil {
+			err = fmt.Errorf("%w: %w", ErrLockLost, lostErr)
+			return
+		}
+		err = unlockErr
+	}()
+	return fn(ctx)
+}
@@ -2,12 +2,16 @@ package _cache
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/LXJ0000/go-combat/cache/mocks"
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/golang/mock/gomock"
 	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -145,3 +149,155 @@ func TestRedisLock_UnLock(t *testing.T) {
 		})
 	}
 }
+
+func newTestLockClient(t *testing.T) (*miniredis.Miniredis, *Client, *clock.FakeClock) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	fake := clock.NewFake(time.Now())
+	return mr, NewClient(rdb, WithClientClock(fake)), fake
+}
+
+func TestRedisLock_Watchdog(t *testing.T) {
+	mr, c, fake := newTestLockClient(t)
+	ctx := context.Background()
+
+	lock, err := c.TryLock(ctx, "key", 3*time.Second, WithWatchdog())
+	require.NoError(t, err)
+
+	// 每隔 expiration/3 续约一次
+	mr.FastForward(2 * time.Second)
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return mr.TTL("key") == 3*time.Second
+	}, time.Second, 10*time.Millisecond)
+	assert.Nil(t, lock.Err())
+
+	// 锁被他人持有之后续约失败
+	require.NoError(t, mr.Set("key", "other"))
+	fake.Advance(time.Second)
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lock not lost")
+	}
+	assert.ErrorIs(t, lock.Err(), ErrLockRefresh)
+	assert.ErrorIs(t, lock.UnLock(), ErrLockNotFound)
+}
+
+func TestRedisLock_WatchdogRefreshTimeout(t *testing.T) {
+	ts := []struct {
+		name string
+		opts []LockOption
+		want time.Duration
+	}{
+		{
+			name: "default",
+			opts: []LockOption{WithWatchdog()},
+			// expiration/3 的 1/3
+			want: time.Second / 3,
+		},
+		{
+			name: "with refresh timeout",
+			opts: []LockOption{WithWatchdog(), WithRefreshTimeout(100 * time.Millisecond)},
+			want: 100 * time.Millisecond,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			cmd := mocks.NewMockCmdable(ctrl)
+			cmd.EXPECT().SetNX(gomock.Any(), "key", gomock.Any(), 3*time.Second).Return(redis.NewBoolResult(true, nil))
+			timeouts := make(chan time.Duration, 1)
+			cmd.EXPECT().Eval(gomock.Any(), luaRefreshExpiration, []string{"key"}, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd {
+					deadline, ok := ctx.Deadline()
+					require.True(t, ok)
+					timeouts <- time.Until(deadline)
+					return redis.NewCmdResult(int64(1), nil)
+				})
+			fake := clock.NewFake(time.Now())
+			c := NewClient(cmd, WithClientClock(fake))
+			_, err := c.TryLock(context.Background(), "key", 3*time.Second, tt.opts...)
+			require.NoError(t, err)
+
+			fake.Advance(time.Second)
+			timeout := <-timeouts
+			assert.LessOrEqual(t, timeout, tt.want)
+			assert.Greater(t, timeout, tt.want-50*time.Millisecond)
+		})
+	}
+}
+
+func TestRedisLock_WatchdogUnLock(t *testing.T) {
+	mr, c, fake := newTestLockClient(t)
+	ctx := context.Background()
+
+	lock, err := c.Lock(ctx, "key", 3*time.Second, time.Second, NewDefaultRetryStrategy(1, time.Second), WithWatchdog())
+	require.NoError(t, err)
+	require.NoError(t, lock.UnLock())
+	assert.False(t, mr.Exists("key"))
+
+	// UnLock 之后 watchdog 退出，不会把锁标记为丢失
+	fake.Advance(time.Second)
+	assert.Never(t, func() bool {
+		return lock.Err() != nil
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	// 重复 UnLock 不会 panic
+	assert.ErrorIs(t, lock.UnLock(), ErrLockNotFound)
+}
+
+func TestClient_WithLock(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	ctx := context.Background()
+	opts := LockOptions{Expiration: 3 * time.Second}
+	bizErr := errors.New("biz error")
+
+	err := c.WithLock(ctx, "key", opts, func(ctx context.Context) error {
+		assert.True(t, mr.Exists("key"))
+		// 锁被持有时其他人加锁失败
+		assert.ErrorIs(t, c.WithLock(ctx, "key", opts, func(ctx context.Context) error {
+			t.Fatal("should not run")
+			return nil
+		}), ErrLockFail)
+		return bizErr
+	})
+	assert.ErrorIs(t, err, bizErr)
+	assert.False(t, mr.Exists("key"))
+
+	require.NoError(t, c.WithLock(ctx, "key", opts, func(ctx context.Context) error {
+		return nil
+	}))
+	assert.False(t, mr.Exists("key"))
+}
+
+func TestClient_WithLockLost(t *testing.T) {
+	mr, c, fake := newTestLockClient(t)
+	err := c.WithLock(context.Background(), "key", LockOptions{Expiration: 3 * time.Second}, func(ctx context.Context) error {
+		require.NoError(t, mr.Set("key", "other"))
+		fake.Advance(time.Second)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("ctx not cancelled")
+		}
+		assert.ErrorIs(t, context.Cause(ctx), ErrLockLost)
+		assert.ErrorIs(t, context.Cause(ctx), ErrLockRefresh)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrLockLost)
+	// 不会删除他人的锁
+	val, _ := mr.Get("key")
+	assert.Equal(t, "other", val)
+}
+
+func TestClient_WithLockPanic(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = c.WithLock(context.Background(), "key", LockOptions{Expiration: 3 * time.Second}, func(ctx context.Context) error {
+			panic("boom")
+		})
+	})
+	assert.False(t, mr.Exists("key"))
+}
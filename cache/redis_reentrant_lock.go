@@ -0,0 +1,58 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	_ "embed"
+)
+
+var (
+	ErrLockOwnerNotFound = errors.New("redis lock: lock owner not found in context")
+
+	//go:embed lua/reentrant_lock.lua
+	luaReentrantLock string
+
+	//go:embed lua/reentrant_unlock.lua
+	luaReentrantUnLock string
+
+	//go:embed lua/reentrant_refresh.lua
+	luaReentrantRefresh string
+)
+
+type lockOwnerKey struct{}
+
+// WithLockOwner 返回携带持有者标识的 ctx
+// 同一个逻辑任务使用同一个 owner，嵌套调用 ReentrantLock 时会重入而不是死锁
+func WithLockOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, lockOwnerKey{}, owner)
+}
+
+// LockOwner returns the lock owner carried by ctx.
+func LockOwner(ctx context.Context) (string, bool) {
+	owner, ok := ctx.Value(lockOwnerKey{}).(string)
+	return owner, ok && owner != ""
+}
+
+// ReentrantLock acquires a reentrant lock for the owner carried by ctx, see WithLockOwner.
+// 锁保存为 hash，field 为 owner，value 为重入次数，加锁、解锁、续约只会延长过期时间，不会缩短
+// 每次成功加锁都要对应一次 UnLock，重入次数减到 0 时才会真正释放锁
+func (c *Client) ReentrantLock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, retry RetryStrategy, opts ...LockOption) (*Lock, error) {
+	owner, ok := LockOwner(ctx)
+	if !ok {
+		return nil, ErrLockOwnerNotFound
+	}
+	err := c.acquire(ctx, contextTimeout, retry, func(ctx context.Context) (bool, error) {
+		cnt, err := c.cmd.Eval(ctx, luaReentrantLock, []string{key}, owner, expiration.Milliseconds()).Int64()
+		return cnt > 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.newLock(key, owner, expiration, lockScripts{
+		keys:    []string{key},
+		unlock:  luaReentrantUnLock,
+		refresh: luaReentrantRefresh,
+	}, opts), nil
+}
@@ -0,0 +1,130 @@
+package _cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ReentrantLock(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	noRetry := NewDefaultRetryStrategy(0, 0)
+	ctx := WithLockOwner(context.Background(), "task-a")
+
+	_, err := c.ReentrantLock(context.Background(), "key", time.Minute, time.Second, noRetry)
+	assert.ErrorIs(t, err, ErrLockOwnerNotFound)
+
+	outer, err := c.ReentrantLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+	inner, err := c.ReentrantLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+	assert.Equal(t, "2", mr.HGet("key", "task-a"))
+
+	// 其他持有者加锁失败
+	_, err = c.ReentrantLock(WithLockOwner(context.Background(), "task-b"), "key", time.Minute, time.Second, noRetry)
+	assert.ErrorIs(t, err, ErrLockFail)
+
+	// 第一次解锁只减少重入次数
+	require.NoError(t, inner.UnLock())
+	assert.Equal(t, "1", mr.HGet("key", "task-a"))
+	require.NoError(t, outer.UnLock())
+	assert.False(t, mr.Exists("key"))
+	assert.ErrorIs(t, outer.UnLock(), ErrLockNotFound)
+}
+
+func TestClient_ReentrantLockNestedExpiration(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	noRetry := NewDefaultRetryStrategy(0, 0)
+	ctx := WithLockOwner(context.Background(), "task-a")
+
+	outer, err := c.ReentrantLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+	// 嵌套加锁、续约、解锁使用更短的过期时间，不会缩短外层的租期
+	inner, err := c.ReentrantLock(ctx, "key", time.Second, time.Second, noRetry)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, mr.TTL("key"))
+	require.NoError(t, inner.Refresh(ctx))
+	assert.Equal(t, time.Minute, mr.TTL("key"))
+	require.NoError(t, inner.UnLock())
+	assert.Equal(t, time.Minute, mr.TTL("key"))
+
+	// 更长的过期时间依旧会延长租期
+	inner, err = c.ReentrantLock(ctx, "key", time.Hour, time.Second, noRetry)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, mr.TTL("key"))
+	require.NoError(t, inner.UnLock())
+	require.NoError(t, outer.UnLock())
+	assert.False(t, mr.Exists("key"))
+}
+
+func TestClient_ReentrantLockRefresh(t *testing.T) {
+	mr, c, fake := newTestLockClient(t)
+	ctx := WithLockOwner(context.Background(), "task-a")
+	lock, err := c.ReentrantLock(ctx, "key", 3*time.Second, time.Second, NewDefaultRetryStrategy(0, 0), WithWatchdog())
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, mr.TTL("key"))
+
+	mr.FastForward(2 * time.Second)
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return mr.TTL("key") == 3*time.Second
+	}, time.Second, 10*time.Millisecond)
+
+	// 锁被删除之后续约失败
+	mr.Del("key")
+	fake.Advance(time.Second)
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lock not lost")
+	}
+	assert.ErrorIs(t, lock.Err(), ErrLockRefresh)
+	assert.ErrorIs(t, lock.UnLock(), ErrLockNotFound)
+}
+
+func TestClient_WithLockReentrant(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	opts := LockOptions{Expiration: time.Minute, Reentrant: true}
+	var calls int
+	err := c.WithLock(context.Background(), "key", opts, func(ctx context.Context) error {
+		owner, ok := LockOwner(ctx)
+		require.True(t, ok)
+		// 嵌套调用重入
+		require.NoError(t, c.WithLock(ctx, "key", opts, func(ctx context.Context) error {
+			calls++
+			assert.Equal(t, "2", mr.HGet("key", owner))
+			return nil
+		}))
+		assert.Equal(t, "1", mr.HGet("key", owner))
+		// 其他任务无法获取
+		assert.ErrorIs(t, c.WithLock(context.Background(), "key", opts, func(ctx context.Context) error {
+			t.Fatal("should not run")
+			return nil
+		}), ErrLockFail)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.False(t, mr.Exists("key"))
+}
+
+func TestClient_ReentrantLockDoubleUnLock(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	noRetry := NewDefaultRetryStrategy(0, 0)
+	ctx := WithLockOwner(context.Background(), "task-a")
+	outer, err := c.ReentrantLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+	inner, err := c.ReentrantLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+
+	// 内层重复解锁不会释放外层持有的锁
+	require.NoError(t, inner.UnLock())
+	assert.ErrorIs(t, inner.UnLock(), ErrLockNotFound)
+	assert.Equal(t, "1", mr.HGet("key", "task-a"))
+
+	require.NoError(t, outer.UnLock())
+	assert.False(t, mr.Exists("key"))
+}
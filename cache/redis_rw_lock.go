@@ -0,0 +1,63 @@
+package _cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	_ "embed"
+)
+
+var (
+	//go:embed lua/rlock.lua
+	luaRLock string
+
+	//go:embed lua/runlock.lua
+	luaRUnLock string
+
+	//go:embed lua/rrefresh.lua
+	luaRRefresh string
+
+	//go:embed lua/wlock.lua
+	luaWLock string
+)
+
+// rwLockKeys 读写锁使用的 key：写锁、读者集合、等待中的写者
+// 使用 hash tag 保证在 redis cluster 中位于同一个 slot
+func rwLockKeys(key string) []string {
+	return []string{"{" + key + "}:writer", "{" + key + "}:readers", "{" + key + "}:wwait"}
+}
+
+// RLock acquires a shared read lock, any number of readers may hold it while no writer holds or waits for the write lock.
+// 读者保存在 zset 中，score 为各自的过期时间，过期的读者在下一次加锁时被清理
+func (c *Client) RLock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, retry RetryStrategy, opts ...LockOption) (*Lock, error) {
+	value := uuid.New().String()
+	keys := rwLockKeys(key)
+	err := c.acquire(ctx, contextTimeout, retry, func(ctx context.Context) (bool, error) {
+		res, err := c.cmd.Eval(ctx, luaRLock, keys, value, expiration.Milliseconds()).Int64()
+		return res == 1, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.newLock(key, value, expiration, lockScripts{keys: keys, unlock: luaRUnLock, refresh: luaRRefresh}, opts), nil
+}
+
+// WLock acquires the exclusive write lock.
+// 有读者时写者登记等待，新的读者无法加锁，已有的读者释放之后写者即可获得锁，避免写者饥饿
+func (c *Client) WLock(ctx context.Context, key string, expiration time.Duration, contextTimeout time.Duration, retry RetryStrategy, opts ...LockOption) (*Lock, error) {
+	value := uuid.New().String()
+	keys := rwLockKeys(key)
+	err := c.acquire(ctx, contextTimeout, retry, func(ctx context.Context) (bool, error) {
+		res, err := c.cmd.Eval(ctx, luaWLock, keys, value, expiration.Milliseconds()).Int64()
+		return res == 1, err
+	})
+	if err != nil {
+		// 放弃等待，清除自己的等待标记，让读者继续加锁
+		_ = c.cmd.Eval(context.Background(), luaUnLock, keys[2:], value).Err()
+		return nil, err
+	}
+	// 写锁与互斥锁相同，KEYS[1] 为写锁
+	return c.newLock(key, value, expiration, lockScripts{keys: keys, unlock: luaUnLock, refresh: luaRefreshExpiration}, opts), nil
+}
@@ -0,0 +1,117 @@
+package _cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RWLock(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	mr.SetTime(time.Now())
+	ctx := context.Background()
+	noRetry := NewDefaultRetryStrategy(0, 0)
+
+	r1, err := c.RLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+	r2, err := c.RLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+
+	// 有读者时写锁失败，放弃之后不会阻塞新的读者
+	_, err = c.WLock(ctx, "key", time.Minute, time.Second, noRetry)
+	assert.ErrorIs(t, err, ErrLockFail)
+	assert.False(t, mr.Exists("{key}:wwait"))
+	r3, err := c.RLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+
+	for _, r := range []*Lock{r1, r2, r3} {
+		require.NoError(t, r.UnLock())
+	}
+	assert.ErrorIs(t, r1.UnLock(), ErrLockNotFound)
+
+	w, err := c.WLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+	_, err = c.RLock(ctx, "key", time.Minute, time.Second, noRetry)
+	assert.ErrorIs(t, err, ErrLockFail)
+	_, err = c.WLock(ctx, "key", time.Minute, time.Second, noRetry)
+	assert.ErrorIs(t, err, ErrLockFail)
+	require.NoError(t, w.Refresh(ctx))
+	require.NoError(t, w.UnLock())
+
+	_, err = c.RLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+}
+
+func TestClient_WLockWriterPreference(t *testing.T) {
+	mr, c, fake := newTestLockClient(t)
+	mr.SetTime(time.Now())
+	ctx := context.Background()
+	noRetry := NewDefaultRetryStrategy(0, 0)
+
+	r, err := c.RLock(ctx, "key", time.Minute, time.Second, noRetry)
+	require.NoError(t, err)
+
+	done := make(chan *Lock, 1)
+	go func() {
+		w, err := c.WLock(ctx, "key", time.Minute, time.Second, NewDefaultRetryStrategy(100, time.Second))
+		assert.NoError(t, err)
+		done <- w
+	}()
+	// 写者等待期间新的读者无法加锁
+	require.Eventually(t, func() bool {
+		return mr.Exists("{key}:wwait")
+	}, time.Second, time.Millisecond)
+	_, err = c.RLock(ctx, "key", time.Minute, time.Second, noRetry)
+	assert.ErrorIs(t, err, ErrLockFail)
+
+	require.NoError(t, r.UnLock())
+	var w *Lock
+	require.Eventually(t, func() bool {
+		fake.Advance(time.Second)
+		select {
+		case w = <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	require.NotNil(t, w)
+	assert.False(t, mr.Exists("{key}:wwait"))
+	require.NoError(t, w.UnLock())
+}
+
+func TestClient_RLockExpiration(t *testing.T) {
+	mr, c, _ := newTestLockClient(t)
+	now := time.Now()
+	mr.SetTime(now)
+	ctx := context.Background()
+	noRetry := NewDefaultRetryStrategy(0, 0)
+
+	r1, err := c.RLock(ctx, "key", time.Second, time.Second, noRetry)
+	require.NoError(t, err)
+	r2, err := c.RLock(ctx, "key", time.Second, time.Second, noRetry)
+	require.NoError(t, err)
+
+	// r1 续约，r2 过期
+	mr.SetTime(now.Add(500 * time.Millisecond))
+	require.NoError(t, r1.Refresh(ctx))
+	mr.SetTime(now.Add(1200 * time.Millisecond))
+	assert.ErrorIs(t, r2.Refresh(ctx), ErrLockRefresh)
+	assert.ErrorIs(t, r2.UnLock(), ErrLockNotFound)
+
+	_, err = c.WLock(ctx, "key", time.Second, time.Second, noRetry)
+	assert.ErrorIs(t, err, ErrLockFail)
+	require.NoError(t, r1.UnLock())
+
+	// 读者过期之后写者可以加锁
+	r3, err := c.RLock(ctx, "key", time.Second, time.Second, noRetry)
+	require.NoError(t, err)
+	mr.SetTime(now.Add(3 * time.Second))
+	w, err := c.WLock(ctx, "key", time.Second, time.Second, noRetry)
+	require.NoError(t, err)
+	assert.ErrorIs(t, r3.UnLock(), ErrLockNotFound)
+	require.NoError(t, w.UnLock())
+}
@@ -15,36 +15,35 @@ func TestRedisCache_Set(t *testing.T) {
 	ts := []struct {
 		name       string
 		key        string
-		value      interface{}
+		value      []byte
 		expiration time.Duration
 		mock       func(ctrl *gomock.Controller) redis.Cmdable
 
 		wantErr error
-		want    interface{}
 	}{
 		{
 			name:       "success",
 			key:        "test",
-			value:      "test",
+			value:      []byte("test"),
 			expiration: time.Second,
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				cmd := mocks.NewMockCmdable(ctrl)
 				status := redis.NewStatusCmd(context.Background())
 				status.SetVal("OK")
-				cmd.EXPECT().Set(context.Background(), "test", "test", time.Second).Return(status)
+				cmd.EXPECT().Set(context.Background(), "test", []byte("test"), time.Second).Return(status)
 				return cmd
 			},
 		},
 		{
 			name:       "timeout",
 			key:        "test",
-			value:      "test",
+			value:      []byte("test"),
 			expiration: time.Second,
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				cmd := mocks.NewMockCmdable(ctrl)
 				status := redis.NewStatusCmd(context.Background())
 				status.SetErr(context.DeadlineExceeded)
-				cmd.EXPECT().Set(context.Background(), "test", "test", time.Second).Return(status)
+				cmd.EXPECT().Set(context.Background(), "test", []byte("test"), time.Second).Return(status)
 				return cmd
 			},
 			wantErr: context.DeadlineExceeded,
@@ -70,7 +69,7 @@ func TestRedisCache_Get(t *testing.T) {
 		mock func(ctrl *gomock.Controller) redis.Cmdable
 
 		wantErr error
-		want    interface{}
+		want    []byte
 	}{
 		{
 			name: "success",
@@ -82,7 +81,31 @@ func TestRedisCache_Get(t *testing.T) {
 				cmd.EXPECT().Get(context.Background(), "test").Return(status)
 				return cmd
 			},
-			want: "test",
+			want: []byte("test"),
+		},
+		{
+			name: "key not found",
+			key:  "test",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				status := redis.NewStringCmd(context.Background())
+				status.SetErr(redis.Nil)
+				cmd.EXPECT().Get(context.Background(), "test").Return(status)
+				return cmd
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "timeout",
+			key:  "test",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				status := redis.NewStringCmd(context.Background())
+				status.SetErr(context.DeadlineExceeded)
+				cmd.EXPECT().Get(context.Background(), "test").Return(status)
+				return cmd
+			},
+			wantErr: context.DeadlineExceeded,
 		},
 	}
 	for _, tt := range ts {
@@ -93,10 +116,97 @@ func TestRedisCache_Get(t *testing.T) {
 				cmd: tt.mock(ctrl),
 			}
 			val, err := c.Get(context.Background(), tt.key)
-			require.Equal(t, tt.wantErr, err)
-			if err != nil {
+			require.ErrorIs(t, err, tt.wantErr)
+			if err == nil {
 				require.Equal(t, tt.want, val)
 			}
 		})
 	}
 }
+
+func TestRedisCache_Delete(t *testing.T) {
+	ts := []struct {
+		name  string
+		key   string
+		evict bool
+		mock  func(ctrl *gomock.Controller) redis.Cmdable
+
+		wantErr     error
+		wantEvicted []string
+	}{
+		{
+			name: "without evict",
+			key:  "test",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewIntCmd(context.Background())
+				res.SetVal(1)
+				cmd.EXPECT().Del(context.Background(), "test").Return(res)
+				return cmd
+			},
+		},
+		{
+			name:  "with evict",
+			key:   "test",
+			evict: true,
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewStringCmd(context.Background())
+				res.SetVal("value")
+				cmd.EXPECT().GetDel(context.Background(), "test").Return(res)
+				return cmd
+			},
+			wantEvicted: []string{"test"},
+		},
+		{
+			name:  "with evict key not found",
+			key:   "test",
+			evict: true,
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewStringCmd(context.Background())
+				res.SetErr(redis.Nil)
+				cmd.EXPECT().GetDel(context.Background(), "test").Return(res)
+				return cmd
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c := NewRedisCache(tt.mock(ctrl))
+			var evicted []string
+			if tt.evict {
+				c.OnEvicted(func(key string, value []byte) {
+					evicted = append(evicted, key)
+				})
+			}
+			err := c.Delete(context.Background(), tt.key)
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.wantEvicted, evicted)
+		})
+	}
+}
+
+func TestRedisCache_LoadAndDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cmd := mocks.NewMockCmdable(ctrl)
+	found := redis.NewStringCmd(context.Background())
+	found.SetVal("value")
+	notFound := redis.NewStringCmd(context.Background())
+	notFound.SetErr(redis.Nil)
+	gomock.InOrder(
+		cmd.EXPECT().GetDel(context.Background(), "test").Return(found),
+		cmd.EXPECT().GetDel(context.Background(), "test").Return(notFound),
+	)
+	c := NewRedisCache(cmd)
+
+	val, err := c.LoadAndDelete(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), val)
+
+	_, err = c.LoadAndDelete(context.Background(), "test")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
@@ -0,0 +1,134 @@
+package _cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+)
+
+type RefreshAheadCacheOption func(*RefreshAheadCache)
+
+// WithRefreshFactor 剩余过期时间小于 factor*过期时间 时读取会触发异步刷新，默认 0.5
+func WithRefreshFactor(factor float64) RefreshAheadCacheOption {
+	return func(c *RefreshAheadCache) {
+		c.factor = factor
+	}
+}
+
+// WithRefreshClock 指定时钟，同时用于判断过期与后台加载的超时，测试中可以传入 clock.FakeClock
+func WithRefreshClock(clock clock.Clock) RefreshAheadCacheOption {
+	return func(c *RefreshAheadCache) {
+		c.clock = clock
+	}
+}
+
+// RefreshAheadCache 提前刷新：读到即将过期的 key 时异步调用 LoadFunc 重新加载，热点 key 不会真正过期
+// Cache 接口拿不到剩余过期时间，所以只有通过本对象写入的 key 才会被提前刷新
+type RefreshAheadCache struct {
+	ReadThroughCache
+	keyTracker[refreshEntry]
+	factor float64
+}
+
+type refreshEntry struct {
+	deadline   time.Time
+	expiration time.Duration
+}
+
+// NewRefreshAheadCache 默认在剩余过期时间不足一半时刷新，底层缓存淘汰的 key 不再刷新，监听淘汰事件请使用 RefreshAheadCache.OnEvicted
+func NewRefreshAheadCache(cache Cache, loadFunc func(ctx context.Context, key string) ([]byte, error),
+	expiration time.Duration, opts ...RefreshAheadCacheOption,
+) *RefreshAheadCache {
+	c := &RefreshAheadCache{
+		ReadThroughCache: ReadThroughCache{
+			Cache:      cache,
+			LoadFunc:   loadFunc,
+			Expiration: expiration,
+			clock:      clock.New(),
+		},
+		factor: 0.5,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.watch(cache)
+	return c
+}
+
+// Get returns the value from the cache and refreshes it in the background when it is about to expire.
+func (c *RefreshAheadCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.getCached(ctx, key)
+	if err != nil {
+		if !c.shouldLoad(err) {
+			return nil, err
+		}
+		// 不会触发 OnEvicted 的缓存（例如 redis 中过期的 key）在这里停止跟踪
+		c.untrack(key)
+		return c.wait(ctx, c.g.DoChan(key, func() (any, error) {
+			return c.refresh(ctx, key)
+		}))
+	}
+	if c.shouldRefresh(key) {
+		// 与 Get 共用 singleflight，同一个 key 同时只有一个加载
+		c.g.DoChan(key, func() (any, error) {
+			return c.refresh(ctx, key)
+		})
+	}
+	return value, nil
+}
+
+// Set sets the value for the given key, keys without expiration are never refreshed.
+func (c *RefreshAheadCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if err := c.Cache.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	c.track(key, expiration)
+	return nil
+}
+
+// Delete deletes the value for the given key.
+func (c *RefreshAheadCache) Delete(ctx context.Context, key string) error {
+	c.untrack(key)
+	return c.Cache.Delete(ctx, key)
+}
+
+// LoadAndDelete returns the value for the given key and deletes it from the cache.
+func (c *RefreshAheadCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	c.untrack(key)
+	return c.ReadThroughCache.LoadAndDelete(ctx, key)
+}
+
+// refresh 加载数据并写入缓存，使用独立的 ctx，不会随着触发刷新的请求结束而取消
+func (c *RefreshAheadCache) refresh(ctx context.Context, key string) ([]byte, error) {
+	ctx, cancel := c.detach(ctx)
+	defer cancel()
+	value, err := c.load(ctx, key)
+	if err != nil {
+		slog.Error("refresh ahead cache: load data error", slog.String("key", key), slog.String("error", err.Error()))
+		c.setNegative(ctx, key, err)
+		return nil, err
+	}
+	if err := c.Set(ctx, key, value, c.expiration()); err != nil {
+		slog.Error("refresh ahead cache: set data error", slog.String("key", key), slog.String("error", err.Error()))
+	}
+	return value, nil
+}
+
+func (c *RefreshAheadCache) shouldRefresh(key string) bool {
+	entry, ok := c.tracked(key)
+	if !ok {
+		return false
+	}
+	remaining := entry.deadline.Sub(c.clock.Now())
+	return remaining < time.Duration(c.factor*float64(entry.expiration))
+}
+
+// track 没有过期时间的 key 不需要刷新，停止跟踪
+func (c *RefreshAheadCache) track(key string, expiration time.Duration) {
+	deadline := c.clock.Now().Add(expiration)
+	c.update(key, func(refreshEntry) (refreshEntry, bool) {
+		return refreshEntry{deadline: deadline, expiration: expiration}, expiration > 0
+	})
+}
@@ -0,0 +1,136 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshAheadCache(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Hour, WithClock(fake))
+	defer local.Close()
+	var loads atomic.Int32
+	c := NewRefreshAheadCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		n := loads.Add(1)
+		return []byte(key + strconv.Itoa(int(n))), nil
+	}, 10*time.Second, WithRefreshFactor(0.2), WithRefreshClock(fake))
+	ctx := context.Background()
+
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key1"), val)
+
+	// 剩余 5s，大于 0.2*10s，不刷新
+	fake.Advance(5 * time.Second)
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key1"), val)
+	assert.Equal(t, int32(1), loads.Load())
+
+	// 剩余 1s，返回旧值并在后台刷新
+	fake.Advance(4 * time.Second)
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key1"), val)
+	assert.Eventually(t, func() bool {
+		val, err := local.Get(ctx, "key")
+		return err == nil && string(val) == "key2"
+	}, time.Second, 10*time.Millisecond)
+
+	// 刷新之后重新计算过期时间，原来的过期时间点已经不会过期
+	fake.Advance(2 * time.Second)
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key2"), val)
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestRefreshAheadCache_Singleflight(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Hour, WithClock(fake))
+	defer local.Close()
+	var loads atomic.Int32
+	release := make(chan struct{})
+	c := NewRefreshAheadCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		loads.Add(1)
+		<-release
+		return []byte("value"), nil
+	}, 10*time.Second, WithRefreshClock(fake))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("old"), 10*time.Second))
+	fake.Advance(9 * time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := c.Get(ctx, "key")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("old"), val)
+		}()
+	}
+	wg.Wait()
+	close(release)
+	assert.Eventually(t, func() bool {
+		val, err := local.Get(ctx, "key")
+		return err == nil && string(val) == "value"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), loads.Load())
+}
+
+func TestRefreshAheadCache_Untrack(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Second, WithClock(fake))
+	defer local.Close()
+	c := NewRefreshAheadCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		return []byte(key), nil
+	}, 10*time.Second, WithRefreshClock(fake))
+	var evicted atomic.Int32
+	c.OnEvicted(func(key string, value []byte) {
+		evicted.Add(1)
+	})
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Set(ctx, "key"+strconv.Itoa(i), []byte("value"), 5*time.Second))
+	}
+	assert.Len(t, c.entries, 10)
+
+	// 过期清理之后不再跟踪，用户的回调依旧被调用
+	fake.Advance(6 * time.Second)
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.entries) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(10), evicted.Load())
+}
+
+func TestRefreshAheadCache_UntrackOnMiss(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	// redis 中过期的 key 不会触发 OnEvicted
+	c := NewRefreshAheadCache(NewRedisCache(rdb), func(ctx context.Context, key string) ([]byte, error) {
+		return nil, errors.New("load error")
+	}, 10*time.Second)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), 5*time.Second))
+	assert.Len(t, c.entries, 1)
+
+	mr.FastForward(6 * time.Second)
+	_, err := c.Get(ctx, "key")
+	assert.Error(t, err)
+	assert.Empty(t, c.entries)
+}
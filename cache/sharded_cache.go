@@ -0,0 +1,83 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"time"
+)
+
+var _ Cache = &ShardedCache{}
+
+// ShardedCache 将 key 哈希到多个独立的 LocalCache 上
+// 每个分片有自己的锁和自己的过期清理 goroutine，降低单把锁的竞争
+type ShardedCache struct {
+	shards []*LocalCache
+	mask   uint64
+	seed   maphash.Seed
+}
+
+// NewShardedCache shards 会向上取整为 2 的幂，opts 会应用到每一个分片上
+func NewShardedCache(shards int, interval time.Duration, opts ...LocalCacheOption) *ShardedCache {
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	c := &ShardedCache{
+		shards: make([]*LocalCache, n),
+		mask:   uint64(n - 1),
+		seed:   maphash.MakeSeed(),
+	}
+	for i := range c.shards {
+		c.shards[i] = NewLocalCache(interval, opts...)
+	}
+	return c
+}
+
+func (c *ShardedCache) shard(key string) *LocalCache {
+	return c.shards[maphash.String(c.seed, key)&c.mask]
+}
+
+// Get returns the value for the given key.
+func (c *ShardedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.shard(key).Get(ctx, key)
+}
+
+// Set sets the value for the given key with an optional expiration time.
+// if expiration is zero, the value will not expire.
+func (c *ShardedCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return c.shard(key).Set(ctx, key, value, expiration)
+}
+
+// Delete deletes the value for the given key.
+func (c *ShardedCache) Delete(ctx context.Context, key string) error {
+	return c.shard(key).Delete(ctx, key)
+}
+
+// Exists checks if the given key exists in the cache.
+func (c *ShardedCache) Exists(ctx context.Context, key string) bool {
+	return c.shard(key).Exists(ctx, key)
+}
+
+// LoadAndDelete returns the value for the given key and deletes it from the cache.
+func (c *ShardedCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	return c.shard(key).LoadAndDelete(ctx, key)
+}
+
+// OnEvicted sets the callback function on every shard.
+func (c *ShardedCache) OnEvicted(fn func(key string, value []byte)) {
+	for _, s := range c.shards {
+		s.OnEvicted(fn)
+	}
+}
+
+// Close closes all shards.
+func (c *ShardedCache) Close() error {
+	var errs []error
+	for _, s := range c.shards {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package _cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedCache(t *testing.T) {
+	var mu sync.Mutex
+	var evicted []string
+	c := NewShardedCache(3, time.Minute, WithEvict(func(key string, value []byte) {
+		mu.Lock()
+		evicted = append(evicted, key)
+		mu.Unlock()
+	}))
+	defer c.Close()
+	assert.Len(t, c.shards, 4)
+
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		key := "key" + strconv.Itoa(i)
+		require.NoError(t, c.Set(ctx, key, []byte(key), 0))
+	}
+	for i := 0; i < 100; i++ {
+		key := "key" + strconv.Itoa(i)
+		val, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(key), val)
+	}
+
+	_, err := c.Get(ctx, "not-exist")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, c.Delete(ctx, "key1"))
+	assert.False(t, c.Exists(ctx, "key1"))
+	val, err := c.LoadAndDelete(ctx, "key2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key2"), val)
+	assert.Equal(t, []string{"key1", "key2"}, evicted)
+}
+
+func BenchmarkLocalCache_Parallel(b *testing.B) {
+	c := NewLocalCache(time.Minute)
+	defer c.Close()
+	benchmarkCacheParallel(b, c)
+}
+
+func BenchmarkShardedCache_Parallel(b *testing.B) {
+	c := NewShardedCache(32, time.Minute)
+	defer c.Close()
+	benchmarkCacheParallel(b, c)
+}
+
+// benchmarkCacheParallel 读写比例 9:1
+func benchmarkCacheParallel(b *testing.B, c Cache) {
+	const keys = 1 << 16
+	ctx := context.Background()
+	for i := 0; i < keys; i++ {
+		_ = c.Set(ctx, strconv.Itoa(i), []byte("value"), time.Minute)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := strconv.Itoa(i % keys)
+			if i%10 == 0 {
+				_ = c.Set(ctx, key, []byte("value"), time.Minute)
+			} else {
+				_, _ = c.Get(ctx, key)
+			}
+			i++
+		}
+	})
+}
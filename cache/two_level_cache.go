@@ -0,0 +1,229 @@
+package _cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ Cache = &TwoLevelCache{}
+
+// InvalidationMessage 某个实例修改了 Keys，其他实例需要删除本地缓存中的这些 key
+type InvalidationMessage struct {
+	Source string   `json:"source"` // 发出消息的实例，实例会忽略自己发出的消息
+	Keys   []string `json:"keys"`
+}
+
+// InvalidationBus 在多个实例之间广播失效消息
+type InvalidationBus interface {
+	Publish(ctx context.Context, msg InvalidationMessage) error
+	// Subscribe 在订阅建立之后返回，之后在后台调用 handler，直到 ctx 被取消
+	Subscribe(ctx context.Context, handler func(msg InvalidationMessage)) error
+}
+
+// PubSubClient redis.Client 与 redis.ClusterClient 都实现了该接口
+type PubSubClient interface {
+	Publish(ctx context.Context, channel string, message any) *redis.IntCmd
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+var _ InvalidationBus = &RedisInvalidationBus{}
+
+// RedisInvalidationBus 基于 Redis pub/sub 实现的 InvalidationBus
+type RedisInvalidationBus struct {
+	client  PubSubClient
+	channel string
+}
+
+func NewRedisInvalidationBus(client PubSubClient, channel string) *RedisInvalidationBus {
+	return &RedisInvalidationBus{
+		client:  client,
+		channel: channel,
+	}
+}
+
+func (b *RedisInvalidationBus) Publish(ctx context.Context, msg InvalidationMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+func (b *RedisInvalidationBus) Subscribe(ctx context.Context, handler func(msg InvalidationMessage)) error {
+	ps := b.client.Subscribe(ctx, b.channel)
+	// 等待订阅确认，保证返回之后发布的消息都能收到
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return err
+	}
+	go func() {
+		defer ps.Close()
+		ch := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+				var msg InvalidationMessage
+				if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+					slog.Error("redis invalidation bus: unmarshal message error", slog.String("payload", m.Payload), slog.String("error", err.Error()))
+					continue
+				}
+				handler(msg)
+			}
+		}
+	}()
+	return nil
+}
+
+type TwoLevelCacheOption func(*TwoLevelCache)
+
+// WithL1Expiration 本地缓存的过期时间，默认一分钟
+// 本地缓存只是 Redis 的副本，过期时间应该比较短，作为丢失失效消息时的兜底
+func WithL1Expiration(expiration time.Duration) TwoLevelCacheOption {
+	return func(c *TwoLevelCache) {
+		c.l1Expiration = expiration
+	}
+}
+
+// TwoLevelCache 本地缓存(L1) + Redis(L2) 的多级缓存
+// 读：L1 -> L2，L2 命中后回填 L1
+// 写：先写 L2，再删除本实例的 L1，最后广播失效消息让其他实例删除各自的 L1，之后的读取再从 L2 回填
+type TwoLevelCache struct {
+	l1           Cache
+	l2           Cache
+	bus          InvalidationBus
+	id           string
+	l1Expiration time.Duration
+	// versions 删除 L1 时增加 key 的版本号
+	// 回填 L1 前检查读 L2 期间 key 是否被修改过，避免把旧值写回 L1
+	versions *keyVersions
+	cancel   context.CancelFunc
+}
+
+// NewTwoLevelCache 会订阅 bus，调用 Close 取消订阅
+func NewTwoLevelCache(l1 Cache, l2 Cache, bus InvalidationBus, opts ...TwoLevelCacheOption) (*TwoLevelCache, error) {
+	c := &TwoLevelCache{
+		l1:           l1,
+		l2:           l2,
+		bus:          bus,
+		id:           uuid.New().String(),
+		l1Expiration: time.Minute,
+		versions:     newKeyVersions(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := bus.Subscribe(ctx, c.invalidate); err != nil {
+		cancel()
+		return nil, fmt.Errorf("two level cache: subscribe error: %w", err)
+	}
+	c.cancel = cancel
+	return c, nil
+}
+
+// Get reads L1 first, then L2, and back-fills L1 on an L2 hit.
+func (c *TwoLevelCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.l1.Get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		return nil, err
+	}
+	version := c.versions.load(key)
+	value, err = c.l2.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	s := c.versions.lock(key)
+	defer s.unlock()
+	if s.version == version {
+		if err := c.l1.Set(ctx, key, value, c.l1Expiration); err != nil {
+			slog.Error("two level cache: back-fill l1 error", slog.String("key", key), slog.String("error", err.Error()))
+		}
+	}
+	return value, nil
+}
+
+// Set writes L2 and drops the local L1, then notifies other instances.
+func (c *TwoLevelCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if err := c.l2.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	c.invalidateL1(key)
+	return c.publish(ctx, key)
+}
+
+// Delete deletes the key from both levels and notifies other instances.
+func (c *TwoLevelCache) Delete(ctx context.Context, key string) error {
+	if err := c.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.invalidateL1(key)
+	return c.publish(ctx, key)
+}
+
+// Exists checks if the given key exists in either level.
+func (c *TwoLevelCache) Exists(ctx context.Context, key string) bool {
+	return c.l1.Exists(ctx, key) || c.l2.Exists(ctx, key)
+}
+
+// LoadAndDelete returns the value from L2 and deletes the key from both levels.
+func (c *TwoLevelCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.l2.LoadAndDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidateL1(key)
+	return value, c.publish(ctx, key)
+}
+
+// OnEvicted sets the callback on L2, L1 evictions are only local copies being dropped.
+func (c *TwoLevelCache) OnEvicted(fn func(key string, value []byte)) {
+	c.l2.OnEvicted(fn)
+}
+
+// Close stops receiving invalidation messages.
+func (c *TwoLevelCache) Close() error {
+	c.cancel()
+	return nil
+}
+
+func (c *TwoLevelCache) publish(ctx context.Context, keys ...string) error {
+	err := c.bus.Publish(ctx, InvalidationMessage{Source: c.id, Keys: keys})
+	if err != nil {
+		return fmt.Errorf("two level cache: publish invalidation error: %w", err)
+	}
+	return nil
+}
+
+func (c *TwoLevelCache) invalidate(msg InvalidationMessage) {
+	if msg.Source == c.id {
+		return
+	}
+	for _, key := range msg.Keys {
+		c.invalidateL1(key)
+	}
+}
+
+// invalidateL1 增加版本号并删除 L1，与 Get 的回填互斥
+func (c *TwoLevelCache) invalidateL1(key string) {
+	s := c.versions.lock(key)
+	defer s.unlock()
+	s.version++
+	if err := c.l1.Delete(context.Background(), key); err != nil {
+		slog.Error("two level cache: invalidate l1 error", slog.String("key", key), slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,216 @@
+package _cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/cache/mocks"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoLevelCache_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		before  func(t *testing.T, l1 *LocalCache)
+		want    []byte
+		wantErr error
+		wantL1  bool
+	}{
+		{
+			name: "l1 hit",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				return mocks.NewMockCmdable(ctrl)
+			},
+			before: func(t *testing.T, l1 *LocalCache) {
+				require.NoError(t, l1.Set(context.Background(), "key", []byte("l1"), 0))
+			},
+			want:   []byte("l1"),
+			wantL1: true,
+		},
+		{
+			name: "l2 hit back-fill l1",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewStringCmd(context.Background())
+				res.SetVal("l2")
+				cmd.EXPECT().Get(gomock.Any(), "key").Return(res)
+				return cmd
+			},
+			want:   []byte("l2"),
+			wantL1: true,
+		},
+		{
+			name: "l2 miss",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewStringCmd(context.Background())
+				res.SetErr(redis.Nil)
+				cmd.EXPECT().Get(gomock.Any(), "key").Return(res)
+				return cmd
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "l2 error",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewStringCmd(context.Background())
+				res.SetErr(context.DeadlineExceeded)
+				cmd.EXPECT().Get(gomock.Any(), "key").Return(res)
+				return cmd
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			l1 := NewLocalCache(time.Minute)
+			defer l1.Close()
+			if tt.before != nil {
+				tt.before(t, l1)
+			}
+			c, err := NewTwoLevelCache(l1, NewRedisCache(tt.mock(ctrl)), &memoryInvalidationBus{})
+			require.NoError(t, err)
+			defer c.Close()
+
+			val, err := c.Get(context.Background(), "key")
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, val)
+			assert.Equal(t, tt.wantL1, l1.Exists(context.Background(), "key"))
+		})
+	}
+}
+
+func TestTwoLevelCache_Invalidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	newInstance := func() (*TwoLevelCache, *LocalCache) {
+		l1 := NewLocalCache(time.Minute)
+		t.Cleanup(func() { _ = l1.Close() })
+		c, err := NewTwoLevelCache(l1, NewRedisCache(client), NewRedisInvalidationBus(client, "cache:invalidation"))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = c.Close() })
+		return c, l1
+	}
+	a, aL1 := newInstance()
+	b, bL1 := newInstance()
+
+	require.NoError(t, a.Set(ctx, "key", []byte("v1"), time.Minute))
+	// 写入只删除本地缓存，不会回填
+	assert.False(t, aL1.Exists(ctx, "key"))
+	// 等待 b 收到失效消息，否则 b 会认为读 L2 期间数据被修改而放弃回填
+	require.Eventually(t, func() bool {
+		return b.versions.load("key") == 1
+	}, time.Second, time.Millisecond*10)
+	val, err := b.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), val)
+	require.True(t, bL1.Exists(ctx, "key"))
+	_, err = a.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, aL1.Exists(ctx, "key"))
+
+	// a 修改之后两个实例的本地缓存都被删除，再次读取拿到新值
+	require.NoError(t, a.Set(ctx, "key", []byte("v2"), time.Minute))
+	assert.False(t, aL1.Exists(ctx, "key"))
+	require.Eventually(t, func() bool {
+		return !bL1.Exists(ctx, "key")
+	}, time.Second, time.Millisecond*10)
+	require.Eventually(t, func() bool {
+		return b.versions.load("key") == 2
+	}, time.Second, time.Millisecond*10)
+	val, err = b.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), val)
+	_, err = a.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, aL1.Exists(ctx, "key"))
+
+	require.NoError(t, b.Delete(ctx, "key"))
+	require.Eventually(t, func() bool {
+		return !aL1.Exists(ctx, "key")
+	}, time.Second, time.Millisecond*10)
+	_, err = a.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestTwoLevelCache_InvalidateDuringGet(t *testing.T) {
+	tests := []struct {
+		name string
+		// key 读 L2 期间被其他实例修改的 key
+		key    func(c *TwoLevelCache) string
+		wantL1 bool
+	}{
+		{
+			name:   "same key",
+			key:    func(c *TwoLevelCache) string { return "key" },
+			wantL1: false,
+		},
+		{
+			name: "other key",
+			key: func(c *TwoLevelCache) string {
+				// 找一个与 key 不在同一段的 key
+				for i := 0; ; i++ {
+					other := fmt.Sprintf("other%d", i)
+					if c.versions.shard(other) != c.versions.shard("key") {
+						return other
+					}
+				}
+			},
+			wantL1: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			l1 := NewLocalCache(time.Minute)
+			defer l1.Close()
+			var c *TwoLevelCache
+			cmd := mocks.NewMockCmdable(ctrl)
+			cmd.EXPECT().Get(gomock.Any(), "key").DoAndReturn(func(ctx context.Context, key string) *redis.StringCmd {
+				c.invalidate(InvalidationMessage{Source: "other", Keys: []string{tt.key(c)}})
+				res := redis.NewStringCmd(ctx)
+				res.SetVal("old")
+				return res
+			})
+			c, err := NewTwoLevelCache(l1, NewRedisCache(cmd), &memoryInvalidationBus{})
+			require.NoError(t, err)
+			defer c.Close()
+
+			val, err := c.Get(context.Background(), "key")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("old"), val)
+			assert.Equal(t, tt.wantL1, l1.Exists(context.Background(), "key"))
+		})
+	}
+}
+
+// memoryInvalidationBus 进程内的 InvalidationBus，同步调用所有订阅者
+type memoryInvalidationBus struct {
+	handlers []func(msg InvalidationMessage)
+}
+
+func (b *memoryInvalidationBus) Publish(ctx context.Context, msg InvalidationMessage) error {
+	for _, h := range b.handlers {
+		h(msg)
+	}
+	return nil
+}
+
+func (b *memoryInvalidationBus) Subscribe(ctx context.Context, handler func(msg InvalidationMessage)) error {
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
@@ -0,0 +1,60 @@
+package _cache
+
+import (
+	"context"
+	"time"
+)
+
+// TypedCache 在任意 Cache 之上通过 Codec 直接存取结构体
+type TypedCache[T any] struct {
+	cache Cache
+	codec Codec
+}
+
+func NewTypedCache[T any](cache Cache, codec Codec) *TypedCache[T] {
+	return &TypedCache[T]{
+		cache: cache,
+		codec: codec,
+	}
+}
+
+// Get returns the decoded value for the given key.
+func (c *TypedCache[T]) Get(ctx context.Context, key string) (T, error) {
+	var val T
+	data, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return val, err
+	}
+	err = c.codec.Unmarshal(data, &val)
+	return val, err
+}
+
+// Set encodes the value and sets it with an optional expiration time.
+func (c *TypedCache[T]) Set(ctx context.Context, key string, val T, expiration time.Duration) error {
+	data, err := c.codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, key, data, expiration)
+}
+
+// Delete deletes the value for the given key.
+func (c *TypedCache[T]) Delete(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, key)
+}
+
+// Exists checks if the given key exists in the cache.
+func (c *TypedCache[T]) Exists(ctx context.Context, key string) bool {
+	return c.cache.Exists(ctx, key)
+}
+
+// LoadAndDelete returns the decoded value for the given key and deletes it from the cache.
+func (c *TypedCache[T]) LoadAndDelete(ctx context.Context, key string) (T, error) {
+	var val T
+	data, err := c.cache.LoadAndDelete(ctx, key)
+	if err != nil {
+		return val, err
+	}
+	err = c.codec.Unmarshal(data, &val)
+	return val, err
+}
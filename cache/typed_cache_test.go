@@ -0,0 +1,85 @@
+package _cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedUser struct {
+	ID   int64
+	Name string
+}
+
+// protoUser 模拟 protobuf 生成的代码
+type protoUser struct {
+	ID int64
+}
+
+func (u *protoUser) Marshal() ([]byte, error) {
+	return binary.AppendVarint(nil, u.ID), nil
+}
+
+func (u *protoUser) Unmarshal(data []byte) error {
+	id, n := binary.Varint(data)
+	if n <= 0 {
+		return errors.New("invalid data")
+	}
+	u.ID = id
+	return nil
+}
+
+func TestTypedCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+	}{
+		{name: "json", codec: JSONCodec{}},
+		{name: "gob", codec: GobCodec{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := NewLocalCache(time.Minute)
+			defer local.Close()
+			c := NewTypedCache[typedUser](local, tt.codec)
+			ctx := context.Background()
+			want := typedUser{ID: 1, Name: "tom"}
+			require.NoError(t, c.Set(ctx, "user:1", want, time.Minute))
+			assert.True(t, c.Exists(ctx, "user:1"))
+
+			got, err := c.Get(ctx, "user:1")
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			got, err = c.LoadAndDelete(ctx, "user:1")
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			_, err = c.Get(ctx, "user:1")
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+		})
+	}
+}
+
+func TestTypedCache_Binary(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	ctx := context.Background()
+
+	c := NewTypedCache[*protoUser](local, BinaryCodec{})
+	require.NoError(t, c.Set(ctx, "user:1", &protoUser{ID: 42}, time.Minute))
+	got, err := c.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, &protoUser{ID: 42}, got)
+
+	// 没有实现二进制编解码的类型
+	bad := NewTypedCache[typedUser](local, BinaryCodec{})
+	assert.Error(t, bad.Set(ctx, "user:2", typedUser{}, time.Minute))
+	_, err = bad.Get(ctx, "user:1")
+	assert.Error(t, err)
+}
@@ -23,3 +23,18 @@ type Cache interface {
 
 	OnEvicted(func(key string, value []byte))
 }
+
+// BatchCache 支持批量操作的 Cache，一次调用完成多个 key 的读写，减少网络往返
+// LocalCache 在一次加锁内完成，RedisCache 使用 MGET 与 pipeline
+type BatchCache interface {
+	Cache
+
+	// MGet returns the values of the keys that exist, missing or expired keys are absent from the result.
+	MGet(ctx context.Context, keys []string) (map[string][]byte, error)
+
+	// MSet sets all entries with the same expiration time.
+	MSet(ctx context.Context, entries map[string][]byte, expiration time.Duration) error
+
+	// MDelete deletes the values for the given keys, missing keys are ignored.
+	MDelete(ctx context.Context, keys []string) error
+}
@@ -0,0 +1,83 @@
+package _cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WriteAroundCache 写绕过缓存：写操作只写存储并删除缓存，下一次读取时再通过 LoadFunc 加载
+// 适合写多读少、写入的数据短期内不会被读取的场景
+type WriteAroundCache struct {
+	ReadThroughCache
+	StoreFunc func(ctx context.Context, key string, val []byte) error
+	// versions Set 删除缓存时增加 key 的版本号，加载期间版本号变化说明可能读到了旧值，不写入缓存
+	versions *keyVersions
+}
+
+func NewWriteAroundCache(cache Cache,
+	loadFunc func(ctx context.Context, key string) ([]byte, error),
+	storeFunc func(ctx context.Context, key string, val []byte) error,
+	expiration time.Duration,
+) *WriteAroundCache {
+	return &WriteAroundCache{
+		ReadThroughCache: ReadThroughCache{
+			Cache:      cache,
+			LoadFunc:   loadFunc,
+			Expiration: expiration,
+		},
+		StoreFunc: storeFunc,
+		versions:  newKeyVersions(),
+	}
+}
+
+// Get returns the value from the cache, loading it through singleflight on a miss.
+func (c *WriteAroundCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.getCached(ctx, key)
+	if err != nil {
+		if !c.shouldLoad(err) {
+			return nil, err
+		}
+		return c.wait(ctx, c.g.DoChan(key, func() (any, error) {
+			return c.loadAndFill(ctx, key)
+		}))
+	}
+	return value, nil
+}
+
+// Set writes the value to the store and invalidates the cache, the expiration is ignored.
+func (c *WriteAroundCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	if err := c.StoreFunc(ctx, key, val); err != nil {
+		return err
+	}
+	// 正在进行的加载可能读到了旧值，之后的读取不再复用它
+	c.g.Forget(key)
+	s := c.versions.lock(key)
+	defer s.unlock()
+	s.version++
+	return c.Cache.Delete(ctx, key)
+}
+
+// loadAndFill 加载数据，加载期间 key 没有被 Set 过才写入缓存
+func (c *WriteAroundCache) loadAndFill(ctx context.Context, key string) ([]byte, error) {
+	ctx, cancel := c.detach(ctx)
+	defer cancel()
+	version := c.versions.load(key)
+	value, err := c.load(ctx, key)
+
+	s := c.versions.lock(key)
+	defer s.unlock()
+	if err != nil {
+		if s.version == version {
+			c.setNegative(ctx, key, err)
+		}
+		return nil, err
+	}
+	if s.version != version {
+		return value, nil
+	}
+	if err := c.Cache.Set(ctx, key, value, c.expiration()); err != nil {
+		slog.Error("write around cache: set data error", slog.String("key", key), slog.String("error", err.Error()))
+	}
+	return value, nil
+}
@@ -0,0 +1,78 @@
+package _cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAroundCache(t *testing.T) {
+	var mu sync.Mutex
+	db := map[string][]byte{"key1": []byte("value1")}
+	var loads atomic.Int32
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	c := NewWriteAroundCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		loads.Add(1)
+		mu.Lock()
+		defer mu.Unlock()
+		return db[key], nil
+	}, func(ctx context.Context, key string, val []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		db[key] = val
+		return nil
+	}, time.Minute)
+	ctx := context.Background()
+
+	val, err := c.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	assert.True(t, local.Exists(ctx, "key1"))
+
+	// 写操作只写存储，并删除缓存
+	require.NoError(t, c.Set(ctx, "key1", []byte("value2"), time.Minute))
+	assert.False(t, local.Exists(ctx, "key1"))
+	assert.Equal(t, []byte("value2"), db["key1"])
+
+	val, err = c.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value2"), val)
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestWriteAroundCache_SetDuringLoad(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	defer local.Close()
+	loading := make(chan struct{})
+	stored := make(chan struct{})
+	c := NewWriteAroundCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		// 读到旧值之后，Set 写入了新值
+		close(loading)
+		<-stored
+		return []byte("old"), nil
+	}, func(ctx context.Context, key string, val []byte) error {
+		return nil
+	}, time.Minute)
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		val, err := c.Get(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("old"), val)
+	}()
+	<-loading
+	require.NoError(t, c.Set(ctx, "key", []byte("new"), time.Minute))
+	close(stored)
+	<-done
+
+	// 旧值不会写回缓存
+	assert.False(t, local.Exists(ctx, "key"))
+}
@@ -0,0 +1,253 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+)
+
+var _ Cache = &WriteBackCache{}
+
+type WriteBackCacheOption func(*WriteBackCache)
+
+// WithFlushInterval 定时刷盘的间隔，默认一秒
+func WithFlushInterval(interval time.Duration) WriteBackCacheOption {
+	return func(c *WriteBackCache) {
+		c.interval = interval
+	}
+}
+
+// WithFlushBatchSize 每次调用 StoreFunc 最多写入的 key 数量，默认 100
+func WithFlushBatchSize(size int) WriteBackCacheOption {
+	return func(c *WriteBackCache) {
+		c.batchSize = size
+	}
+}
+
+// WithWriteBackClock 指定定时刷盘使用的时钟，测试中可以传入 clock.FakeClock
+func WithWriteBackClock(clock clock.Clock) WriteBackCacheOption {
+	return func(c *WriteBackCache) {
+		c.clock = clock
+	}
+}
+
+// WriteBackCache 写回（write-behind）模式
+// Set 只写缓存并标记为脏数据，由后台定时、或者脏数据被淘汰时批量写入存储
+// Delete 会丢弃尚未写入存储的数据，但不会删除存储中已有的数据
+type WriteBackCache struct {
+	Cache
+	StoreFunc func(ctx context.Context, entries map[string][]byte) error
+
+	interval  time.Duration
+	batchSize int
+	clock     clock.Clock
+
+	// keys 同一个 key 的 Set、Delete 串行执行，保证缓存与脏数据的修改顺序一致
+	// 不能使用 mu，Cache.Set 可能同步触发 onEvicted
+	keys *keyVersions
+
+	mu      sync.Mutex
+	dirty   map[string]*dirtyEntry
+	version uint64
+	closed  bool
+	evict   func(key string, value []byte)
+	writing sync.WaitGroup // 进行中的 Set，Close 等待它们结束之后再刷盘
+
+	flushMu sync.Mutex // 同一时间只有一个 flush
+	trigger chan struct{}
+	close   chan struct{}
+	done    chan struct{}
+}
+
+type dirtyEntry struct {
+	value   []byte
+	version uint64 // 写入存储成功后，只有版本没有变化才清除脏标记
+}
+
+// NewWriteBackCache 每隔 interval 在后台刷盘一次，cache 的 OnEvicted 回调发现脏数据被淘汰时会立即触发一次刷盘
+// 因此不要再直接设置 cache 的回调，监听淘汰事件请使用 WriteBackCache.OnEvicted
+func NewWriteBackCache(cache Cache, storeFunc func(ctx context.Context, entries map[string][]byte) error, opts ...WriteBackCacheOption) *WriteBackCache {
+	c := &WriteBackCache{
+		Cache:     cache,
+		StoreFunc: storeFunc,
+		interval:  time.Second,
+		batchSize: 100,
+		clock:     clock.New(),
+		keys:      newKeyVersions(),
+		dirty:     make(map[string]*dirtyEntry),
+		trigger:   make(chan struct{}, 1),
+		close:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	cache.OnEvicted(c.onEvicted)
+	go c.loop(c.clock.NewTicker(c.interval))
+	return c
+}
+
+func (c *WriteBackCache) loop(ticker clock.Ticker) {
+	defer close(c.done)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.close:
+			return
+		case <-ticker.C():
+		case <-c.trigger:
+		}
+		if err := c.Flush(context.Background()); err != nil {
+			slog.Error("write back cache: flush error", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Get returns the value for the given key, falling back to dirty data which has been evicted but not yet stored.
+func (c *WriteBackCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if !errors.Is(err, ErrKeyNotFound) {
+		return value, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.dirty[key]; ok {
+		return entry.value, nil
+	}
+	return nil, err
+}
+
+// Set writes the value to the cache and marks it dirty.
+func (c *WriteBackCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return fmt.Errorf("write back cache: %w", ErrCacheClosed)
+	}
+	c.writing.Add(1)
+	c.mu.Unlock()
+	defer c.writing.Done()
+	s := c.keys.lock(key)
+	defer s.unlock()
+	if err := c.Cache.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.dirty[key] = &dirtyEntry{value: value, version: c.version}
+	return nil
+}
+
+// Delete deletes the key from the cache and drops its dirty data.
+func (c *WriteBackCache) Delete(ctx context.Context, key string) error {
+	s := c.keys.lock(key)
+	defer s.unlock()
+	c.mu.Lock()
+	delete(c.dirty, key)
+	c.mu.Unlock()
+	return c.Cache.Delete(ctx, key)
+}
+
+// LoadAndDelete deletes the key like Delete and returns its value, including dirty data which has been evicted.
+func (c *WriteBackCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	s := c.keys.lock(key)
+	defer s.unlock()
+	c.mu.Lock()
+	entry, dirty := c.dirty[key]
+	delete(c.dirty, key)
+	c.mu.Unlock()
+	value, err := c.Cache.LoadAndDelete(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) && dirty {
+		return entry.value, nil
+	}
+	return value, err
+}
+
+// OnEvicted sets the callback function which is called when a key is deleted, expired or evicted.
+func (c *WriteBackCache) OnEvicted(fn func(key string, value []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict = fn
+}
+
+// Flush blocks until every entry that is dirty at the time of the call has been stored.
+// 写入失败的数据保留脏标记，等待下一次刷盘
+func (c *WriteBackCache) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	pending := make(map[string]*dirtyEntry, len(c.dirty))
+	for key, entry := range c.dirty {
+		pending[key] = entry
+	}
+	c.mu.Unlock()
+
+	batch := make(map[string][]byte, c.batchSize)
+	for key, entry := range pending {
+		batch[key] = entry.value
+		if len(batch) >= c.batchSize {
+			if err := c.store(ctx, batch, pending); err != nil {
+				return err
+			}
+			batch = make(map[string][]byte, c.batchSize)
+		}
+	}
+	if len(batch) > 0 {
+		return c.store(ctx, batch, pending)
+	}
+	return nil
+}
+
+// Close stops the background flushing, rejects new writes and flushes all dirty entries.
+func (c *WriteBackCache) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return fmt.Errorf("write back cache: %w", ErrCacheClosed)
+	}
+	c.closed = true
+	c.mu.Unlock()
+	c.writing.Wait()
+	close(c.close)
+	<-c.done
+	return c.Flush(ctx)
+}
+
+func (c *WriteBackCache) store(ctx context.Context, batch map[string][]byte, pending map[string]*dirtyEntry) error {
+	if err := c.StoreFunc(ctx, batch); err != nil {
+		return fmt.Errorf("write back cache: store error: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range batch {
+		// 写入期间又被 Set 过的 key 保留脏标记
+		if entry, ok := c.dirty[key]; ok && entry.version == pending[key].version {
+			delete(c.dirty, key)
+		}
+	}
+	return nil
+}
+
+func (c *WriteBackCache) onEvicted(key string, value []byte) {
+	c.mu.Lock()
+	_, dirty := c.dirty[key]
+	evict := c.evict
+	c.mu.Unlock()
+	if dirty {
+		// 脏数据被淘汰，尽快写入存储
+		select {
+		case c.trigger <- struct{}{}:
+		default:
+		}
+	}
+	if evict != nil {
+		evict(key, value)
+	}
+}
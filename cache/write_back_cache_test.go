@@ -0,0 +1,270 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore 记录 StoreFunc 的调用
+type memoryStore struct {
+	mu      sync.Mutex
+	data    map[string][]byte
+	batches []int
+	err     error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{data: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Store(ctx context.Context, entries map[string][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, len(entries))
+	for key, value := range entries {
+		s.data[key] = value
+	}
+	return nil
+}
+
+func (s *memoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.data)
+}
+
+func TestWriteBackCache_Flush(t *testing.T) {
+	store := newMemoryStore()
+	local := NewLocalCache(time.Minute)
+	c := NewWriteBackCache(local, store.Store, WithFlushInterval(time.Hour), WithFlushBatchSize(2))
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		key := "key" + strconv.Itoa(i)
+		require.NoError(t, c.Set(ctx, key, []byte(key), 0))
+	}
+	assert.Equal(t, 0, store.Len())
+
+	require.NoError(t, c.Flush(ctx))
+	assert.Equal(t, 5, store.Len())
+	assert.ElementsMatch(t, []int{2, 2, 1}, store.batches)
+
+	// 没有脏数据时不会调用 StoreFunc
+	require.NoError(t, c.Flush(ctx))
+	assert.Len(t, store.batches, 3)
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestWriteBackCache_FlushError(t *testing.T) {
+	store := newMemoryStore()
+	store.err = errors.New("db down")
+	c := NewWriteBackCache(NewLocalCache(time.Minute), store.Store, WithFlushInterval(time.Hour))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+
+	assert.ErrorIs(t, c.Flush(ctx), store.err)
+	// 写入失败保留脏标记，下次刷盘重试
+	store.mu.Lock()
+	store.err = nil
+	store.mu.Unlock()
+	require.NoError(t, c.Flush(ctx))
+	assert.Equal(t, []byte("value1"), store.data["key1"])
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestWriteBackCache_Interval(t *testing.T) {
+	store := newMemoryStore()
+	fake := clock.NewFake(time.Now())
+	c := NewWriteBackCache(NewLocalCache(time.Minute), store.Store, WithFlushInterval(time.Second), WithWriteBackClock(fake))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	fake.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return store.Len() == 1
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestWriteBackCache_Evicted(t *testing.T) {
+	store := newMemoryStore()
+	var mu sync.Mutex
+	var evicted []string
+	c := NewWriteBackCache(NewMaxCntCache(1, NewLocalCache(time.Minute)), store.Store, WithFlushInterval(time.Hour))
+	c.OnEvicted(func(key string, value []byte) {
+		mu.Lock()
+		evicted = append(evicted, key)
+		mu.Unlock()
+	})
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+
+	// key1 被淘汰，但还没有写入存储，依旧可以读到
+	val, err := c.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value1"), val)
+
+	assert.Eventually(t, func() bool {
+		return store.Len() == 2
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"key1"}, evicted)
+	mu.Unlock()
+
+	_, err = c.Get(ctx, "key1")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestWriteBackCache_Close(t *testing.T) {
+	store := newMemoryStore()
+	c := NewWriteBackCache(NewLocalCache(time.Minute), store.Store, WithFlushInterval(time.Hour))
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		key := "key" + strconv.Itoa(i)
+		require.NoError(t, c.Set(ctx, key, []byte(key), 0))
+	}
+	require.NoError(t, c.Close(ctx))
+	assert.Equal(t, 10, store.Len())
+
+	assert.ErrorIs(t, c.Set(ctx, "key", []byte("value"), 0), ErrCacheClosed)
+	assert.ErrorIs(t, c.Close(ctx), ErrCacheClosed)
+}
+
+func TestWriteBackCache_Delete(t *testing.T) {
+	store := newMemoryStore()
+	local := NewMaxCntCache(1, NewLocalCache(time.Minute))
+	c := NewWriteBackCache(local, store.Store, WithFlushInterval(time.Hour))
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "key1", []byte("value1"), 0))
+	require.NoError(t, c.Delete(ctx, "key1"))
+	_, err := c.Get(ctx, "key1")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	// key2 被淘汰之后依旧可以 LoadAndDelete
+	store.mu.Lock()
+	store.err = errors.New("store error")
+	store.mu.Unlock()
+	require.NoError(t, c.Set(ctx, "key2", []byte("value2"), 0))
+	require.NoError(t, c.Set(ctx, "key3", []byte("value3"), 0))
+	assert.False(t, local.Exists(ctx, "key2"))
+	val, err := c.LoadAndDelete(ctx, "key2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value2"), val)
+	_, err = c.Get(ctx, "key2")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	// 删除的 key 不会写入存储
+	store.mu.Lock()
+	store.err = nil
+	store.mu.Unlock()
+	require.NoError(t, c.Close(ctx))
+	assert.Equal(t, map[string][]byte{"key3": []byte("value3")}, store.data)
+}
+
+func TestWriteBackCache_GetError(t *testing.T) {
+	local := NewLocalCache(time.Minute)
+	c := NewWriteBackCache(local, newMemoryStore().Store, WithFlushInterval(time.Hour))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), 0))
+	require.NoError(t, local.Close())
+	// 只有未命中时才读取脏数据
+	_, err := c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrCacheClosed)
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestWriteBackCache_SetClose(t *testing.T) {
+	store := newMemoryStore()
+	c := NewWriteBackCache(NewLocalCache(time.Minute), store.Store, WithFlushInterval(time.Hour))
+	ctx := context.Background()
+	var mu sync.Mutex
+	var written []string
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				key := strconv.Itoa(i) + "-" + strconv.Itoa(j)
+				if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+					assert.ErrorIs(t, err, ErrCacheClosed)
+					return
+				}
+				mu.Lock()
+				written = append(written, key)
+				mu.Unlock()
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, c.Close(ctx))
+	wg.Wait()
+	// 成功的 Set 都已经写入存储
+	require.NotEmpty(t, written)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, key := range written {
+		_, ok := store.data[key]
+		assert.True(t, ok, key)
+	}
+}
+
+func TestWriteBackCache_SetDelete(t *testing.T) {
+	store := newMemoryStore()
+	local := NewLocalCache(time.Minute)
+	written := make(chan struct{})
+	resume := make(chan struct{})
+	c := NewWriteBackCache(&blockingSetCache{Cache: local, written: written, resume: resume}, store.Store, WithFlushInterval(time.Hour))
+	ctx := context.Background()
+
+	setErr := make(chan error, 1)
+	go func() {
+		setErr <- c.Set(ctx, "key", []byte("value"), 0)
+	}()
+	<-written
+	// Set 已经写入缓存但还没有标记脏数据，Delete 需要等待 Set 结束
+	deleteErr := make(chan error, 1)
+	go func() {
+		deleteErr <- c.Delete(ctx, "key")
+	}()
+	select {
+	case err := <-deleteErr:
+		t.Fatalf("delete returned before set: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(resume)
+	require.NoError(t, <-setErr)
+	require.NoError(t, <-deleteErr)
+
+	// 被删除的数据不会写入存储
+	require.NoError(t, c.Flush(ctx))
+	assert.Equal(t, 0, store.Len())
+	assert.False(t, c.Exists(ctx, "key"))
+	require.NoError(t, c.Close(ctx))
+}
+
+// blockingSetCache Set 写入缓存之后通知 written，等待 resume 之后才返回
+type blockingSetCache struct {
+	Cache
+	written chan struct{}
+	resume  chan struct{}
+}
+
+func (c *blockingSetCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	err := c.Cache.Set(ctx, key, value, expiration)
+	close(c.written)
+	<-c.resume
+	return err
+}
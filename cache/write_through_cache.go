@@ -6,35 +6,50 @@ import (
 	"time"
 )
 
+type WriteThroughCacheOption func(*WriteThroughCache)
+
+// WithWriteThroughJitter 写入缓存的过期时间随机浮动 ±jitter（例如 0.1 代表 ±10%）
+func WithWriteThroughJitter(jitter float64) WriteThroughCacheOption {
+	return func(c *WriteThroughCache) {
+		c.Jitter = jitter
+	}
+}
+
 type WriteThroughCache struct {
 	Cache
 	StoreFunc func(ctx context.Context, key string, val []byte) error
+	// Jitter 大于 0 时 Set 的过期时间随机浮动 ±Jitter
+	Jitter float64
 }
 
-func NewWriteThroughCache(store Cache, storeFunc func(ctx context.Context, key string, val []byte) error) *WriteThroughCache {
-	return &WriteThroughCache{
+func NewWriteThroughCache(store Cache, storeFunc func(ctx context.Context, key string, val []byte) error, opts ...WriteThroughCacheOption) *WriteThroughCache {
+	c := &WriteThroughCache{
 		Cache:     store,
 		StoreFunc: storeFunc,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Set writes the value to the cache and the underlying store.
 func (c *WriteThroughCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
-    if err := c.StoreFunc(ctx, key, val); err != nil {
+	if err := c.StoreFunc(ctx, key, val); err != nil {
 		return err
 	}
-	return c.Cache.Set(ctx, key, val, expiration)
+	return c.Cache.Set(ctx, key, val, jitterExpiration(expiration, c.Jitter))
 }
 
 // SetAsync writes the value to the cache and the underlying store asynchronously.
 func (c *WriteThroughCache) SetAsync(ctx context.Context, key string, val []byte, expiration time.Duration) error {
-    if err := c.StoreFunc(ctx, key, val); err != nil {
+	if err := c.StoreFunc(ctx, key, val); err != nil {
 		return err
 	}
-	go func ()  {
-		if err :=  c.Cache.Set(ctx, key, val, expiration); err != nil {
+	go func() {
+		if err := c.Cache.Set(ctx, key, val, jitterExpiration(expiration, c.Jitter)); err != nil {
 			slog.Error("write through cache: set data error", slog.String("key", key), slog.Any("val", val), slog.String("error", err.Error()))
 		}
 	}()
 	return nil
-}
\ No newline at end of file
+}
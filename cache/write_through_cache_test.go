@@ -0,0 +1,40 @@
+package _cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteThroughCache_Jitter(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Hour, WithClock(fake))
+	defer local.Close()
+	c := NewWriteThroughCache(local, func(ctx context.Context, key string, val []byte) error {
+		return nil
+	}, WithWriteThroughJitter(0.5))
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Set(ctx, strconv.Itoa(i), []byte("value"), 10*time.Second))
+	}
+	// 过期时间分布在 [5s, 15s]
+	fake.Advance(10 * time.Second)
+	cnt := 0
+	for i := 0; i < 100; i++ {
+		if _, err := local.Get(ctx, strconv.Itoa(i)); err == nil {
+			cnt++
+		}
+	}
+	assert.Greater(t, cnt, 0)
+	assert.Less(t, cnt, 100)
+	fake.Advance(5*time.Second + time.Millisecond)
+	for i := 0; i < 100; i++ {
+		_, err := local.Get(ctx, strconv.Itoa(i))
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	}
+}
@@ -0,0 +1,153 @@
+package _cache
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+)
+
+type XFetchCacheOption func(*XFetchCache)
+
+// WithXFetchBeta beta 越大越倾向于提前重新加载，默认 1
+func WithXFetchBeta(beta float64) XFetchCacheOption {
+	return func(c *XFetchCache) {
+		c.beta = beta
+	}
+}
+
+// WithXFetchClock 指定时钟，同时用于判断过期与后台加载的超时，测试中可以传入 clock.FakeClock
+func WithXFetchClock(clock clock.Clock) XFetchCacheOption {
+	return func(c *XFetchCache) {
+		c.clock = clock
+	}
+}
+
+// XFetchCache 概率提前过期（XFetch）：
+// 每次读取时以 now - delta*beta*ln(rand) >= expiry 判断是否提前重新加载，
+// delta 为上一次加载耗时，越接近过期、加载越慢，越可能提前重新加载
+// 与 RefreshAheadCache 不同，触发的请求会同步加载，其他请求依旧读取缓存，避免缓存过期瞬间的并发加载
+type XFetchCache struct {
+	ReadThroughCache
+	keyTracker[xfetchEntry]
+	beta float64
+	rand func() float64 // 返回 [0, 1)，测试中可以替换
+}
+
+type xfetchEntry struct {
+	expiry time.Time
+	delta  time.Duration
+}
+
+// NewXFetchCache 默认 beta 为 1，只通过 Set 写入、还没有加载过的 key 没有耗时记录，不会提前重新加载
+// 监听淘汰事件请使用 XFetchCache.OnEvicted
+func NewXFetchCache(cache Cache, loadFunc func(ctx context.Context, key string) ([]byte, error),
+	expiration time.Duration, opts ...XFetchCacheOption,
+) *XFetchCache {
+	c := &XFetchCache{
+		ReadThroughCache: ReadThroughCache{
+			Cache:      cache,
+			LoadFunc:   loadFunc,
+			Expiration: expiration,
+			clock:      clock.New(),
+		},
+		beta: 1,
+		rand: rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.watch(cache)
+	return c
+}
+
+// Get returns the value from the cache, reloading it early with a probability growing as it approaches expiry.
+func (c *XFetchCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.getCached(ctx, key)
+	if err != nil {
+		if !c.shouldLoad(err) {
+			return nil, err
+		}
+		// 不会触发 OnEvicted 的缓存（例如 redis 中过期的 key）在这里停止跟踪
+		c.untrack(key)
+		return c.loadWithSingleflight(ctx, key)
+	}
+	if !c.shouldRecompute(key) {
+		return value, nil
+	}
+	val, err := c.loadWithSingleflight(ctx, key)
+	if err != nil {
+		// 提前加载失败，缓存中的值依旧有效
+		return value, nil
+	}
+	return val, nil
+}
+
+// Set sets the value for the given key.
+func (c *XFetchCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if err := c.Cache.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	// 沿用上一次加载的耗时
+	c.track(key, expiration, func(entry xfetchEntry) time.Duration {
+		return entry.delta
+	})
+	return nil
+}
+
+// Delete deletes the value for the given key.
+func (c *XFetchCache) Delete(ctx context.Context, key string) error {
+	c.untrack(key)
+	return c.Cache.Delete(ctx, key)
+}
+
+// LoadAndDelete returns the value for the given key and deletes it from the cache.
+func (c *XFetchCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	c.untrack(key)
+	return c.ReadThroughCache.LoadAndDelete(ctx, key)
+}
+
+func (c *XFetchCache) loadWithSingleflight(ctx context.Context, key string) ([]byte, error) {
+	return c.wait(ctx, c.g.DoChan(key, func() (any, error) {
+		ctx, cancel := c.detach(ctx)
+		defer cancel()
+		start := c.clock.Now()
+		value, err := c.load(ctx, key)
+		if err != nil {
+			slog.Error("xfetch cache: load data error", slog.String("key", key), slog.String("error", err.Error()))
+			c.setNegative(ctx, key, err)
+			return nil, err
+		}
+		delta := c.clock.Now().Sub(start)
+		expiration := c.expiration()
+		if err := c.Cache.Set(ctx, key, value, expiration); err != nil {
+			slog.Error("xfetch cache: set data error", slog.String("key", key), slog.String("error", err.Error()))
+			return value, nil
+		}
+		c.track(key, expiration, func(xfetchEntry) time.Duration {
+			return delta
+		})
+		return value, nil
+	}))
+}
+
+func (c *XFetchCache) shouldRecompute(key string) bool {
+	entry, ok := c.tracked(key)
+	if !ok {
+		return false
+	}
+	// 1-rand 取值 (0, 1]，-ln 取值 [0, +Inf)
+	gap := time.Duration(float64(entry.delta) * c.beta * -math.Log(1-c.rand()))
+	return !c.clock.Now().Add(gap).Before(entry.expiry)
+}
+
+// track delta 以 key 当前的记录计算，没有过期时间的 key 停止跟踪
+func (c *XFetchCache) track(key string, expiration time.Duration, delta func(entry xfetchEntry) time.Duration) {
+	expiry := c.clock.Now().Add(expiration)
+	c.update(key, func(entry xfetchEntry) (xfetchEntry, bool) {
+		return xfetchEntry{expiry: expiry, delta: delta(entry)}, expiration > 0
+	})
+}
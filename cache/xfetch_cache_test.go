@@ -0,0 +1,124 @@
+package _cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJitterExpiration(t *testing.T) {
+	assert.Equal(t, time.Minute, jitterExpiration(time.Minute, 0))
+	assert.Equal(t, time.Duration(0), jitterExpiration(0, 0.1))
+
+	seen := make(map[time.Duration]struct{})
+	for i := 0; i < 100; i++ {
+		exp := jitterExpiration(time.Minute, 0.1)
+		assert.GreaterOrEqual(t, exp, 54*time.Second)
+		assert.LessOrEqual(t, exp, 66*time.Second)
+		seen[exp] = struct{}{}
+	}
+	assert.Greater(t, len(seen), 1)
+}
+
+func TestXFetchCache(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Hour, WithClock(fake))
+	defer local.Close()
+	loads := 0
+	var loadErr error
+	c := NewXFetchCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		loads++
+		fake.Advance(time.Second) // 每次加载耗时 1s
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return []byte(key + strconv.Itoa(loads)), nil
+	}, 10*time.Second, WithXFetchClock(fake))
+	// -ln(1-rand) = 1，提前 delta*beta = 1s 重新加载
+	c.rand = func() float64 { return 1 - math.Exp(-1) }
+	ctx := context.Background()
+
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key1"), val)
+
+	// 距离过期 2s，不会提前加载
+	fake.Advance(8 * time.Second)
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key1"), val)
+	assert.Equal(t, 1, loads)
+
+	// 距离过期 0.5s，提前加载失败时返回缓存中的值
+	fake.Advance(1500 * time.Millisecond)
+	loadErr = errors.New("db down")
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key1"), val)
+	assert.Equal(t, 2, loads)
+
+	// 提前加载成功
+	loadErr = nil
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key3"), val)
+	assert.Equal(t, 3, loads)
+}
+
+func TestXFetchCache_Untrack(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	local := NewLocalCache(time.Second, WithClock(fake))
+	defer local.Close()
+	c := NewXFetchCache(local, func(ctx context.Context, key string) ([]byte, error) {
+		return []byte(key), nil
+	}, 5*time.Second, WithXFetchClock(fake))
+	var evicted atomic.Int32
+	c.OnEvicted(func(key string, value []byte) {
+		evicted.Add(1)
+	})
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		_, err := c.Get(ctx, "key"+strconv.Itoa(i))
+		require.NoError(t, err)
+	}
+	c.mu.Lock()
+	assert.Len(t, c.entries, 10)
+	c.mu.Unlock()
+
+	// 过期清理之后不再跟踪，用户的回调依旧被调用
+	fake.Advance(6 * time.Second)
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.entries) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(10), evicted.Load())
+}
+
+func TestXFetchCache_UntrackOnMiss(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	// redis 中过期的 key 不会触发 OnEvicted
+	c := NewXFetchCache(NewRedisCache(rdb), func(ctx context.Context, key string) ([]byte, error) {
+		return nil, errors.New("load error")
+	}, 10*time.Second)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), 5*time.Second))
+	assert.Len(t, c.entries, 1)
+
+	mr.FastForward(6 * time.Second)
+	_, err := c.Get(ctx, "key")
+	assert.Error(t, err)
+	assert.Empty(t, c.entries)
+}
@@ -0,0 +1,224 @@
+// Package clock 对 time.Now 与 time.Ticker 做一层抽象
+// 业务代码依赖 Clock 接口，测试中使用 FakeClock 手动推进时间，避免真实 sleep
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	// AfterFunc 在 d 之后调用 f
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+type Timer interface {
+	// Stop 阻止 f 被调用，f 已经被调用或者已经停止时返回 false
+	Stop() bool
+}
+
+// New 返回基于 time 包的真实时钟
+func New() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{t: time.NewTicker(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r *realTicker) C() <-chan time.Time { return r.t.C }
+
+func (r *realTicker) Stop() { r.t.Stop() }
+
+func (r *realTicker) Reset(d time.Duration) { r.t.Reset(d) }
+
+// FakeClock 只有调用 Advance/Set 时时间才会前进，并触发到期的 ticker 与 timer
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+	timers  []*fakeTimer
+}
+
+func NewFake(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{
+		clock:  c,
+		c:      make(chan time.Time, 1), // 与 time.Ticker 一致，消费不及时会丢弃 tick
+		period: d,
+		next:   c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// AfterFunc 返回的 timer 到期时，f 在 Advance/Set 返回之前同步调用
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{
+		clock: c,
+		when:  c.now.Add(d),
+		f:     f,
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance 将时间向前推进 d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set 将时间设置为 t，期间到期的 ticker 按照周期依次触发，到期的 timer 按照到期时间依次调用
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	for _, tk := range c.tickers {
+		for !tk.stopped && !tk.next.After(t) {
+			select {
+			case tk.c <- tk.next:
+			default:
+			}
+			tk.next = tk.next.Add(tk.period)
+		}
+	}
+	var due []*fakeTimer
+	pending := c.timers[:0]
+	for _, tm := range c.timers {
+		switch {
+		case tm.done:
+		case !tm.when.After(t):
+			tm.done = true
+			due = append(due, tm)
+		default:
+			pending = append(pending, tm)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	// f 中可能再次调用 FakeClock，不能持有锁
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].when.Before(due[j].when)
+	})
+	for _, tm := range due {
+		tm.f()
+	}
+}
+
+type fakeTicker struct {
+	clock   *FakeClock
+	c       chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = false
+	t.period = d
+	t.next = t.clock.now.Add(d)
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	f     func()
+	done  bool // 已经调用或者已经停止
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	if t.done {
+		return false
+	}
+	t.done = true
+	return true
+}
+
+// WithTimeout 与 context.WithTimeout 相同，但是由 clk 决定何时超时，使用 FakeClock 时只有推进时间才会超时
+func WithTimeout(parent context.Context, clk Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clk.(realClock); ok {
+		return context.WithTimeout(parent, d)
+	}
+	deadline := clk.Now().Add(d)
+	if pd, ok := parent.Deadline(); ok && pd.Before(deadline) {
+		deadline = pd
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	t := clk.AfterFunc(d, func() {
+		cancel(context.DeadlineExceeded)
+	})
+	return &timeoutCtx{Context: ctx, deadline: deadline}, func() {
+		t.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+type timeoutCtx struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *timeoutCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// Err 超时返回 context.DeadlineExceeded，与 context.WithTimeout 一致
+func (c *timeoutCtx) Err() error {
+	err := c.Context.Err()
+	if err != nil && context.Cause(c.Context) == context.DeadlineExceeded {
+		return context.DeadlineExceeded
+	}
+	return err
+}
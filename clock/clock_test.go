@@ -0,0 +1,72 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(base)
+	ticker := c.NewTicker(time.Second)
+
+	c.Advance(time.Millisecond * 500)
+	assert.Equal(t, base.Add(time.Millisecond*500), c.Now())
+	assert.Len(t, ticker.C(), 0)
+
+	c.Advance(time.Millisecond * 500)
+	assert.Equal(t, base.Add(time.Second), <-ticker.C())
+
+	// 跨越多个周期只保留一个 tick，与 time.Ticker 一致
+	c.Advance(time.Second * 3)
+	assert.Equal(t, base.Add(time.Second*2), <-ticker.C())
+	assert.Len(t, ticker.C(), 0)
+
+	ticker.Stop()
+	c.Advance(time.Second * 5)
+	assert.Len(t, ticker.C(), 0)
+
+	ticker.Reset(time.Second)
+	c.Advance(time.Second)
+	assert.Equal(t, base.Add(time.Second*10), <-ticker.C())
+}
+
+func TestFakeClock_AfterFunc(t *testing.T) {
+	c := NewFake(time.Now())
+	var fired []int
+	c.AfterFunc(time.Second*2, func() { fired = append(fired, 2) })
+	c.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+	stopped := c.AfterFunc(time.Second, func() { fired = append(fired, 0) })
+	assert.True(t, stopped.Stop())
+
+	c.Advance(time.Millisecond * 500)
+	assert.Empty(t, fired)
+	// 按照到期时间依次调用，已经停止的不会调用
+	c.Advance(time.Second * 2)
+	assert.Equal(t, []int{1, 2}, fired)
+	assert.False(t, stopped.Stop())
+}
+
+func TestWithTimeout(t *testing.T) {
+	c := NewFake(time.Now())
+	ctx, cancel := WithTimeout(context.Background(), c, time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, c.Now().Add(time.Second), deadline)
+
+	c.Advance(time.Millisecond * 999)
+	assert.NoError(t, ctx.Err())
+	c.Advance(time.Millisecond)
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+
+	// 超时之前调用 cancel
+	ctx, cancel = WithTimeout(context.Background(), c, time.Second)
+	cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
@@ -3,6 +3,8 @@ package ratelimit
 import (
 	"sync"
 	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
 )
 
 type Counter struct {
@@ -11,18 +13,33 @@ type Counter struct {
 	begin time.Time
 	cycle time.Duration
 	mu    sync.Mutex
+	clock clock.Clock
 }
 
-func NewCounter(rate int, cycle time.Duration) *Counter {
-	return &Counter{
-		begin: time.Now(),
+type CounterOption func(*Counter)
+
+// WithClock 指定时钟，测试中可以传入 clock.FakeClock 控制窗口重置
+func WithClock(clock clock.Clock) CounterOption {
+	return func(c *Counter) {
+		c.clock = clock
+	}
+}
+
+func NewCounter(rate int, cycle time.Duration, opts ...CounterOption) *Counter {
+	c := &Counter{
 		cycle: cycle,
 		rate:  rate,
+		clock: clock.New(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.begin = c.clock.Now()
+	return c
 }
 
 func (c *Counter) reset() {
-	c.begin = time.Now()
+	c.begin = c.clock.Now()
 	c.count = 0
 }
 
@@ -30,7 +47,7 @@ func (c *Counter) Allow() bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	now := time.Now()
+	now := c.clock.Now()
 	if now.Sub(c.begin) > c.cycle {
 		c.reset()
 	}
@@ -41,4 +58,4 @@ func (c *Counter) Allow() bool {
 
 	c.count++
 	return true
-}
\ No newline at end of file
+}
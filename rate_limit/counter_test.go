@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LXJ0000/go-combat/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter_Allow(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	c := NewCounter(3, time.Second, WithClock(fake))
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, c.Allow())
+	}
+	assert.False(t, c.Allow())
+
+	// 窗口还没有结束
+	fake.Advance(time.Second)
+	assert.False(t, c.Allow())
+
+	// 进入新的窗口，计数重置
+	fake.Advance(time.Millisecond)
+	for i := 0; i < 3; i++ {
+		assert.True(t, c.Allow())
+	}
+	assert.False(t, c.Allow())
+}
@@ -2,24 +2,20 @@ package main
 
 import (
 	"fmt"
-	"sync"
 	"time"
 
+	"github.com/LXJ0000/go-combat/clock"
 	ratelimit "github.com/LXJ0000/go-combat/rate_limit"
 )
 
 func main() {
-	c := ratelimit.NewCounter(3, time.Second)
-	wg := sync.WaitGroup{}
-	wg.Add(10)
+	// 使用 FakeClock 模拟时间流逝，不需要真实等待
+	clk := clock.NewFake(time.Now())
+	c := ratelimit.NewCounter(3, time.Second, ratelimit.WithClock(clk))
 	for i := 0; i < 10; i++ {
-		go func() {
-			defer wg.Done()
-			if c.Allow() {
-				fmt.Println(i, "allowed", time.Now())
-			}
-		}()
-		time.Sleep(time.Millisecond * 200) // 2秒发送10个请求 有6个通过
+		if c.Allow() {
+			fmt.Println(i, "allowed", clk.Now())
+		}
+		clk.Advance(time.Millisecond * 200) // 2秒发送10个请求 有6个通过
 	}
-	wg.Wait()
 }